@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+const configFileName = ".rcf.yaml"
+
+// 設定ファイル
+// キーはフラグの名前 (例: charset, files-without)、値はフラグに指定する値
+type config struct {
+	path     string
+	defaults map[string][]string
+	presets  map[string]map[string][]string
+}
+
+// 設定ファイルを探し始めるディレクトリ (空の場合は設定ファイルを使わない)
+var configDir = "."
+
+// 指定したディレクトリから親をたどって設定ファイルを探す (見つからない場合は空文字)
+func findConfig(dir string) (string, error) {
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		path := filepath.Join(dir, configFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+func loadConfig(path string) (*config, error) {
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(contents, &values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	c := &config{
+		path:     path,
+		defaults: map[string][]string{},
+		presets:  map[string]map[string][]string{},
+	}
+
+	for key, value := range values {
+		if key != "presets" {
+			if c.defaults[key], err = configValues(value); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, key, err)
+			}
+			continue
+		}
+
+		presets, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: presets must be a mapping", path)
+		}
+		for name, presetValue := range presets {
+			preset, ok := presetValue.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: presets.%s must be a mapping", path, name)
+			}
+
+			c.presets[name] = map[string][]string{}
+			for key, value := range preset {
+				if c.presets[name][key], err = configValues(value); err != nil {
+					return nil, fmt.Errorf("%s: presets.%s.%s: %w", path, name, key, err)
+				}
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// 複数指定できるフラグ (--column など) はリストで指定
+func configValues(value interface{}) ([]string, error) {
+
+	switch v := value.(type) {
+	case []interface{}:
+		values := []string{}
+		for _, item := range v {
+			itemValues, err := configValues(item)
+			if err != nil || len(itemValues) != 1 {
+				return nil, fmt.Errorf("invalid value")
+			}
+			values = append(values, itemValues[0])
+		}
+		return values, nil
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case int:
+		return []string{strconv.Itoa(v)}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	default:
+		return nil, fmt.Errorf("invalid value")
+	}
+}
+
+// デフォルトにプリセットを重ねた値
+func (c *config) merged(presetName string) (map[string][]string, error) {
+
+	values := map[string][]string{}
+	for key, value := range c.defaults {
+		values[key] = value
+	}
+
+	if presetName != "" {
+		preset, ok := c.presets[presetName]
+		if !ok {
+			return nil, fmt.Errorf("preset \"%s\" is not defined in %s", presetName, c.path)
+		}
+		for key, value := range preset {
+			values[key] = value
+		}
+	}
+
+	return values, nil
+}
+
+// コマンドラインで指定されていないフラグに、設定ファイルの値を設定
+func applyConfig(flag *pflag.FlagSet, c *config, presetName string) error {
+
+	values, err := c.merged(presetName)
+	if err != nil {
+		return err
+	}
+
+	// エラーになるキーが常に同じになるよう、名前順に処理
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		f := flag.Lookup(key)
+		if f == nil || key == "help" || key == "preset" {
+			return fmt.Errorf("%s: unknown option \"%s\"", c.path, key)
+		}
+
+		if flag.Changed(key) {
+			continue
+		}
+
+		for _, value := range values[key] {
+			if err := flag.Set(key, value); err != nil {
+				return fmt.Errorf("%s: %s: %w", c.path, key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// 設定ファイルとコマンドラインを合わせた、有効な設定をYAMLで出力
+func showConfig(flag *pflag.FlagSet, configPath string, w io.Writer) error {
+
+	if configPath == "" {
+		fmt.Fprintf(w, "# %s not found\n", configFileName)
+	} else {
+		fmt.Fprintf(w, "# %s\n", configPath)
+	}
+
+	// 指定されていないものもデフォルトの値で出力 (デフォルトが空のものは省略)
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	flag.VisitAll(func(f *pflag.Flag) {
+		if f.Name == "help" || f.Name == "preset" {
+			return
+		}
+		if !f.Changed && (f.DefValue == "" || f.DefValue == "[]") {
+			return
+		}
+
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: f.Name}
+		var value *yaml.Node
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			value = &yaml.Node{Kind: yaml.SequenceNode}
+			for _, item := range slice.GetSlice() {
+				value.Content = append(value.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+			}
+		} else {
+			value = &yaml.Node{Kind: yaml.ScalarNode, Tag: configTag(f.Value.Type()), Value: f.Value.String()}
+		}
+		root.Content = append(root.Content, key, value)
+	})
+
+	if len(root.Content) == 0 {
+		return nil
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+func configTag(flagType string) string {
+
+	switch flagType {
+	case "bool":
+		return "!!bool"
+	case "int":
+		return "!!int"
+	default:
+		return "!!str"
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindConfig(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	config := createFileWriteString(t, d, ".rcf.yaml", "")
+	sub := createDir(t, createDir(t, d, "a"), "b")
+
+	// ACT
+	path, err := findConfig(sub)
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, config, path)
+}
+
+func TestFindConfig_Nearest(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	createFileWriteString(t, d, ".rcf.yaml", "")
+	sub := createDir(t, d, "a")
+	config := createFileWriteString(t, sub, ".rcf.yaml", "")
+
+	// ACT
+	path, err := findConfig(sub)
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, config, path)
+}
+
+func TestLoadConfig(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	config := createFileWriteString(t, d, ".rcf.yaml",
+		"charset: sjis\n"+
+			"max-count: 2\n"+
+			"recursive: true\n"+
+			"presets:\n"+
+			"  csv:\n"+
+			"    column: [name, 3]\n"+
+			"    charset: utf-8\n")
+
+	// ACT
+	c, err := loadConfig(config)
+	require.NoError(t, err)
+
+	values, err := c.merged("csv")
+	require.NoError(t, err)
+
+	// ASSERT
+	assert.Equal(t, map[string][]string{
+		"charset":   {"utf-8"},
+		"max-count": {"2"},
+		"recursive": {"true"},
+		"column":    {"name", "3"},
+	}, values)
+}
+
+func TestLoadConfig_InvalidValue(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	config := createFileWriteString(t, d, ".rcf.yaml", "charset:\n  name: sjis\n")
+
+	// ACT
+	_, err := loadConfig(config)
+
+	// ASSERT
+	assert.EqualError(t, err, filepath.Join(d, ".rcf.yaml")+": charset: invalid value")
+}
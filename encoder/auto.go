@@ -0,0 +1,73 @@
+package encoder
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// 文書内で宣言された文字コードで変換
+// Stringで判定した文字コードを、その後のBytesでも使う
+type AutoEncoder struct {
+	defaultCharset string
+	encoder        Encoder
+}
+
+func (e *AutoEncoder) String(src []byte) (string, error) {
+
+	charset := DetectCharset(src)
+	if charset == "" {
+		charset = e.defaultCharset
+	}
+
+	encoder, err := NewEncoder(charset)
+	if err != nil {
+		return "", err
+	}
+	e.encoder = encoder
+
+	return encoder.String(src)
+}
+
+func (e *AutoEncoder) Bytes(src string) ([]byte, error) {
+
+	if e.encoder == nil {
+		encoder, err := NewEncoder(e.defaultCharset)
+		if err != nil {
+			return nil, err
+		}
+		e.encoder = encoder
+	}
+
+	return e.encoder.Bytes(src)
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+var declarationPatterns = []*regexp.Regexp{
+	// <?xml version="1.0" encoding="Shift_JIS"?>
+	regexp.MustCompile(`^\s*<\?xml\s[^>]*encoding\s*=\s*["']([A-Za-z0-9._:\-]+)["']`),
+	// <meta charset="Shift_JIS"> や <meta http-equiv="Content-Type" content="text/html; charset=Shift_JIS">
+	regexp.MustCompile(`(?i)<meta\s[^>]*charset\s*=\s*["']?([A-Za-z0-9._:\-]+)`),
+}
+
+// XMLやHTMLで宣言されている文字コードを判定 (宣言が無い場合は空文字)
+func DetectCharset(src []byte) string {
+
+	if bytes.HasPrefix(src, utf8BOM) {
+		return "UTF-8"
+	}
+
+	// 宣言は先頭付近にあるはず
+	head := src
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+
+	for _, pattern := range declarationPatterns {
+		if match := pattern.FindSubmatch(head); match != nil {
+			return string(match[1])
+		}
+	}
+
+	return ""
+}
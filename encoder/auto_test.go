@@ -0,0 +1,78 @@
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestDetectCharset(t *testing.T) {
+
+	assert.Equal(t, "Shift_JIS", DetectCharset([]byte(`<?xml version="1.0" encoding="Shift_JIS"?><a/>`)))
+	assert.Equal(t, "euc-jp", DetectCharset([]byte("\n<?xml version='1.0' encoding='euc-jp' ?>")))
+	assert.Equal(t, "shift_jis", DetectCharset([]byte(`<html><head><meta charset="shift_jis"></head></html>`)))
+	assert.Equal(t, "Shift_JIS", DetectCharset([]byte(`<META http-equiv="Content-Type" content="text/html; charset=Shift_JIS">`)))
+	assert.Equal(t, "UTF-8", DetectCharset([]byte("\xEF\xBB\xBF<a/>")))
+	assert.Equal(t, "", DetectCharset([]byte(`<?xml version="1.0"?><a/>`)))
+	assert.Equal(t, "", DetectCharset([]byte(``)))
+}
+
+func TestNewEncoder_Auto(t *testing.T) {
+
+	// ARRANGE
+	str := `<?xml version="1.0" encoding="Shift_JIS"?><a>あいう</a>`
+	bytes, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte(str))
+	require.NoError(t, err)
+
+	// ACT / ASSERT
+	encoder, err := NewEncoder("auto")
+	require.NoError(t, err)
+
+	{
+		result, err := encoder.String(bytes)
+		require.NoError(t, err)
+		assert.Equal(t, str, result)
+	}
+
+	{
+		// 読み込んだ時の文字コードで書き込む
+		result, err := encoder.Bytes(str)
+		require.NoError(t, err)
+		assert.Equal(t, bytes, result)
+	}
+}
+
+func TestNewEncoder_Auto_Default(t *testing.T) {
+
+	// ARRANGE
+	str := "<a>あいう</a>"
+
+	// ACT / ASSERT
+	encoder, err := NewEncoder("auto")
+	require.NoError(t, err)
+
+	{
+		result, err := encoder.String([]byte(str))
+		require.NoError(t, err)
+		assert.Equal(t, str, result)
+	}
+
+	{
+		result, err := encoder.Bytes(str)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(str), result)
+	}
+}
+
+func TestNewEncoder_Auto_Invalid(t *testing.T) {
+
+	// ACT / ASSERT
+	encoder, err := NewEncoder("auto")
+	require.NoError(t, err)
+
+	_, err = encoder.String([]byte(`<?xml version="1.0" encoding="xxxx"?>`))
+	require.Error(t, err)
+	assert.Equal(t, "htmlindex: invalid encoding name", err.Error())
+}
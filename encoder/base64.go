@@ -0,0 +1,17 @@
+package encoder
+
+import "encoding/base64"
+
+// バイト列をBase64の文字列として扱う
+type Base64Encoder struct {
+}
+
+func (e *Base64Encoder) String(src []byte) (string, error) {
+
+	return base64.StdEncoding.EncodeToString(src), nil
+}
+
+func (e *Base64Encoder) Bytes(src string) ([]byte, error) {
+
+	return base64.StdEncoding.DecodeString(src)
+}
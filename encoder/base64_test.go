@@ -0,0 +1,41 @@
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEncoder_Base64(t *testing.T) {
+
+	// ARRANGE
+	str := "AAFwcYCB8P8="
+	bytes := []byte{0x00, 0x01, 0x70, 0x71, 0x80, 0x81, 0xF0, 0xFF}
+
+	// ACT / ASSERT
+	encoder, err := NewEncoder("base64")
+	require.NoError(t, err)
+
+	{
+		result, err := encoder.String(bytes)
+		require.NoError(t, err)
+		assert.Equal(t, str, result)
+	}
+
+	{
+		result, err := encoder.Bytes(str)
+		require.NoError(t, err)
+		assert.Equal(t, bytes, result)
+	}
+}
+
+func TestNewEncoder_Base64_Invalid(t *testing.T) {
+
+	// ACT / ASSERT
+	encoder, err := NewEncoder("base64")
+	require.NoError(t, err)
+
+	_, err = encoder.Bytes("AAFwcYCB8P8")
+	require.Error(t, err)
+}
@@ -1,73 +1,90 @@
-package encoder
-
-import (
-	"bytes"
-	"fmt"
-	"strings"
-)
-
-type BinaryEncoder struct {
-}
-
-func (e *BinaryEncoder) String(src []byte) (string, error) {
-
-	var builder strings.Builder
-	for _, b := range src {
-		builder.WriteString(byteToHex(b))
-	}
-
-	return builder.String(), nil
-}
-
-func (e *BinaryEncoder) Bytes(src string) ([]byte, error) {
-
-	var buf bytes.Buffer
-
-	// 3文字で1つのヘキサ文字(x00)になっている
-	for i := 0; i < len(src); i += 3 {
-
-		end := i + 3
-		if end > len(src) {
-			end = len(src)
-		}
-
-		b, err := hexToByte(src[i:end])
-		if err != nil {
-			return nil, err
-		}
-
-		buf.WriteByte(b)
-	}
-
-	return buf.Bytes(), nil
-}
-
-const hextable = "0123456789ABCDEF"
-
-func byteToHex(b byte) string {
-
-	return string([]byte{'x', hextable[b/16], hextable[b%16]})
-}
-
-func hexToByte(h string) (byte, error) {
-
-	if len(h) != 3 {
-		return 0x00, fmt.Errorf("illegal hex string \"%s\"", h)
-	}
-
-	if h[0] != 'x' {
-		return 0x00, fmt.Errorf("illegal hex string \"%s\"", h)
-	}
-
-	first := strings.IndexByte(hextable, h[1])
-	if first == -1 {
-		return 0x00, fmt.Errorf("illegal hex string \"%s\"", h)
-	}
-
-	second := strings.IndexByte(hextable, h[2])
-	if second == -1 {
-		return 0x00, fmt.Errorf("illegal hex string \"%s\"", h)
-	}
-
-	return byte(first*16 + second), nil
-}
+package encoder
+
+import "fmt"
+
+type BinaryEncoder struct {
+}
+
+func (e *BinaryEncoder) String(src []byte) (string, error) {
+
+	// 1バイトを3文字(x00)で表す
+	dst := make([]byte, len(src)*3)
+	for i, b := range src {
+		dst[i*3] = 'x'
+		dst[i*3+1] = hextable[b>>4]
+		dst[i*3+2] = hextable[b&0x0F]
+	}
+
+	return string(dst), nil
+}
+
+func (e *BinaryEncoder) Bytes(src string) ([]byte, error) {
+
+	dst := make([]byte, 0, (len(src)+2)/3)
+
+	// 3文字で1つのヘキサ文字(x00)になっている
+	for i := 0; i < len(src); i += 3 {
+
+		end := i + 3
+		if end > len(src) {
+			end = len(src)
+		}
+
+		b, err := hexToByte(src[i:end])
+		if err != nil {
+			return nil, err
+		}
+
+		dst = append(dst, b)
+	}
+
+	return dst, nil
+}
+
+// バイト列をそのまま文字列として扱う (文字列はUTF-8として正しくない場合もある)
+type RawEncoder struct {
+}
+
+func (e *RawEncoder) String(src []byte) (string, error) {
+
+	return string(src), nil
+}
+
+func (e *RawEncoder) Bytes(src string) ([]byte, error) {
+
+	return []byte(src), nil
+}
+
+const hextable = "0123456789ABCDEF"
+
+func hexToByte(h string) (byte, error) {
+
+	if len(h) != 3 || h[0] != 'x' {
+		return 0x00, fmt.Errorf("illegal hex string \"%s\"", h)
+	}
+
+	first, ok := hexValue(h[1])
+	if !ok {
+		return 0x00, fmt.Errorf("illegal hex string \"%s\"", h)
+	}
+
+	second, ok := hexValue(h[2])
+	if !ok {
+		return 0x00, fmt.Errorf("illegal hex string \"%s\"", h)
+	}
+
+	return first<<4 | second, nil
+}
+
+// 大文字のみ (BinaryEncoderが出力する形式)
+func hexValue(c byte) (byte, bool) {
+
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+
+	return 0, false
+}
@@ -1,48 +1,61 @@
-package encoder
-
-import (
-	"strings"
-
-	"golang.org/x/text/encoding"
-	"golang.org/x/text/encoding/htmlindex"
-)
-
-type Encoder interface {
-	String([]byte) (string, error)
-	Bytes(string) ([]byte, error)
-}
-
-func NewEncoder(name string) (Encoder, error) {
-
-	if strings.ToLower(name) == "binary" {
-		return &BinaryEncoder{}, nil
-	}
-
-	encoding, err := htmlindex.Get(name)
-	if err != nil {
-		return nil, err
-	}
-
-	return &EncodingEncoder{
-		encoding: encoding,
-	}, nil
-}
-
-type EncodingEncoder struct {
-	encoding encoding.Encoding
-}
-
-func (e *EncodingEncoder) String(src []byte) (string, error) {
-
-	decodedBytes, err := e.encoding.NewDecoder().Bytes(src)
-	if err != nil {
-		return "", err
-	}
-
-	return string(decodedBytes), nil
-}
-
-func (e *EncodingEncoder) Bytes(src string) ([]byte, error) {
-
-	return e.encoding.NewEncoder().Bytes([]byte(src))
-}
+package encoder
+
+import (
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+type Encoder interface {
+	String([]byte) (string, error)
+	Bytes(string) ([]byte, error)
+}
+
+func NewEncoder(name string) (Encoder, error) {
+
+	switch strings.ToLower(name) {
+	case "binary":
+		return &BinaryEncoder{}, nil
+	case "hex":
+		return &HexEncoder{}, nil
+	case "hex-lower":
+		return &HexEncoder{lower: true}, nil
+	case "base64":
+		return &Base64Encoder{}, nil
+	case "latin1":
+		return &Latin1Encoder{}, nil
+	case "auto":
+		return &AutoEncoder{
+			defaultCharset: "UTF-8",
+		}, nil
+	}
+
+	encoding, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncodingEncoder{
+		encoding: encoding,
+	}, nil
+}
+
+type EncodingEncoder struct {
+	encoding encoding.Encoding
+}
+
+func (e *EncodingEncoder) String(src []byte) (string, error) {
+
+	decodedBytes, err := e.encoding.NewDecoder().Bytes(src)
+	if err != nil {
+		return "", err
+	}
+
+	return string(decodedBytes), nil
+}
+
+func (e *EncodingEncoder) Bytes(src string) ([]byte, error) {
+
+	return e.encoding.NewEncoder().Bytes([]byte(src))
+}
@@ -0,0 +1,29 @@
+package encoder
+
+import "encoding/hex"
+
+// バイト列を16進数の文字列(区切りなし)として扱う
+type HexEncoder struct {
+	lower bool
+}
+
+func (e *HexEncoder) String(src []byte) (string, error) {
+
+	if e.lower {
+		return hex.EncodeToString(src), nil
+	}
+
+	dst := make([]byte, len(src)*2)
+	for i, b := range src {
+		dst[i*2] = hextable[b>>4]
+		dst[i*2+1] = hextable[b&0x0F]
+	}
+
+	return string(dst), nil
+}
+
+func (e *HexEncoder) Bytes(src string) ([]byte, error) {
+
+	// 大文字、小文字どちらでも受け付ける
+	return hex.DecodeString(src)
+}
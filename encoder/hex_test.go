@@ -0,0 +1,67 @@
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEncoder_Hex(t *testing.T) {
+
+	// ARRANGE
+	str := "0001707180810AF0FF"
+	bytes := []byte{0x00, 0x01, 0x70, 0x71, 0x80, 0x81, 0x0A, 0xF0, 0xFF}
+
+	// ACT / ASSERT
+	encoder, err := NewEncoder("hex")
+	require.NoError(t, err)
+
+	{
+		result, err := encoder.String(bytes)
+		require.NoError(t, err)
+		assert.Equal(t, str, result)
+	}
+
+	{
+		result, err := encoder.Bytes(str)
+		require.NoError(t, err)
+		assert.Equal(t, bytes, result)
+	}
+}
+
+func TestNewEncoder_HexLower(t *testing.T) {
+
+	// ARRANGE
+	str := "0001707180810af0ff"
+	bytes := []byte{0x00, 0x01, 0x70, 0x71, 0x80, 0x81, 0x0A, 0xF0, 0xFF}
+
+	// ACT / ASSERT
+	encoder, err := NewEncoder("hex-lower")
+	require.NoError(t, err)
+
+	{
+		result, err := encoder.String(bytes)
+		require.NoError(t, err)
+		assert.Equal(t, str, result)
+	}
+
+	{
+		result, err := encoder.Bytes(str)
+		require.NoError(t, err)
+		assert.Equal(t, bytes, result)
+	}
+}
+
+func TestNewEncoder_Hex_Invalid(t *testing.T) {
+
+	// ACT / ASSERT
+	encoder, err := NewEncoder("hex")
+	require.NoError(t, err)
+
+	_, err = encoder.Bytes("00F")
+	require.Error(t, err)
+
+	_, err = encoder.Bytes("0G")
+	require.Error(t, err)
+}
@@ -0,0 +1,38 @@
+package encoder
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// 1バイトを同じ値の1文字(U+0000-U+00FF)として扱う
+// htmlindexの latin1 は windows-1252 となり、0x80-0x9F が別の文字になってしまうので独自に変換
+type Latin1Encoder struct {
+}
+
+func (e *Latin1Encoder) String(src []byte) (string, error) {
+
+	dst := make([]byte, 0, len(src))
+	for _, b := range src {
+		if b < utf8.RuneSelf {
+			dst = append(dst, b)
+		} else {
+			dst = append(dst, byte(0xC0|b>>6), byte(0x80|b&0x3F))
+		}
+	}
+
+	return string(dst), nil
+}
+
+func (e *Latin1Encoder) Bytes(src string) ([]byte, error) {
+
+	dst := make([]byte, 0, len(src))
+	for _, r := range src {
+		if r > 0xFF {
+			return nil, fmt.Errorf("character %q cannot be represented in latin1", r)
+		}
+		dst = append(dst, byte(r))
+	}
+
+	return dst, nil
+}
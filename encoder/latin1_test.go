@@ -0,0 +1,63 @@
+package encoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEncoder_Latin1(t *testing.T) {
+
+	// ARRANGE
+	str := "\u0000a\u007F\u0080\u0081\u009Féÿ"
+	bytes := []byte{0x00, 'a', 0x7F, 0x80, 0x81, 0x9F, 0xE9, 0xFF}
+
+	// ACT / ASSERT
+	encoder, err := NewEncoder("latin1")
+	require.NoError(t, err)
+
+	{
+		result, err := encoder.String(bytes)
+		require.NoError(t, err)
+		assert.Equal(t, str, result)
+	}
+
+	{
+		result, err := encoder.Bytes(str)
+		require.NoError(t, err)
+		assert.Equal(t, bytes, result)
+	}
+}
+
+func TestNewEncoder_Latin1_AllBytes(t *testing.T) {
+
+	// ARRANGE
+	bytes := make([]byte, 256)
+	for i := range bytes {
+		bytes[i] = byte(i)
+	}
+
+	// ACT
+	encoder, err := NewEncoder("latin1")
+	require.NoError(t, err)
+
+	str, err := encoder.String(bytes)
+	require.NoError(t, err)
+	result, err := encoder.Bytes(str)
+	require.NoError(t, err)
+
+	// ASSERT
+	assert.Equal(t, bytes, result)
+}
+
+func TestNewEncoder_Latin1_Invalid(t *testing.T) {
+
+	// ACT / ASSERT
+	encoder, err := NewEncoder("latin1")
+	require.NoError(t, err)
+
+	_, err = encoder.Bytes("あ")
+	require.Error(t, err)
+	assert.Equal(t, `character 'あ' cannot be represented in latin1`, err.Error())
+}
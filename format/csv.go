@@ -0,0 +1,207 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/onozaty/rcf/replace"
+)
+
+type csvFormat struct {
+	columns   []string
+	delimiter byte
+	quote     byte
+	header    bool
+}
+
+// columnsは列名もしくは列番号(1始まり)で指定
+// 列名で指定する場合はヘッダが必要
+func NewCSV(columns []string, delimiter string, quote string, header bool) (Format, error) {
+
+	if len(delimiter) != 1 {
+		return nil, fmt.Errorf("invalid delimiter \"%s\"", delimiter)
+	}
+
+	if len(quote) != 1 || quote == delimiter || quote == "\r" || quote == "\n" {
+		return nil, fmt.Errorf("invalid quote \"%s\"", quote)
+	}
+
+	return &csvFormat{
+		columns:   columns,
+		delimiter: delimiter[0],
+		quote:     quote[0],
+		header:    header,
+	}, nil
+}
+
+type csvField struct {
+	start  int
+	end    int
+	value  string
+	quoted bool
+}
+
+func (f *csvFormat) Replace(s string, replacer replace.Replacer) (string, error) {
+
+	records, err := f.parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	if len(records) == 0 {
+		return s, nil
+	}
+
+	targets, err := f.targetColumns(records[0])
+	if err != nil {
+		return "", err
+	}
+
+	if f.header {
+		// ヘッダは置換対象外
+		records = records[1:]
+	}
+
+	edits := []edit{}
+	for _, record := range records {
+		for i, field := range record {
+			if targets != nil && !targets[i] {
+				continue
+			}
+
+			replaced := replacer.Replace(field.value)
+			if replaced != field.value {
+				edits = append(edits, edit{start: field.start, end: field.end, text: f.encode(replaced, field.quoted)})
+			}
+		}
+	}
+
+	return applyEdits(s, edits), nil
+}
+
+func (f *csvFormat) targetColumns(first []csvField) (map[int]bool, error) {
+
+	if len(f.columns) == 0 {
+		// 列の指定が無い場合は全ての列 (nilで表す)
+		return nil, nil
+	}
+
+	targets := map[int]bool{}
+
+	for _, column := range f.columns {
+		if index, err := strconv.Atoi(column); err == nil {
+			if index < 1 {
+				return nil, fmt.Errorf("invalid column \"%s\"", column)
+			}
+			targets[index-1] = true
+			continue
+		}
+
+		if !f.header {
+			return nil, fmt.Errorf("column \"%s\" requires a header", column)
+		}
+
+		found := false
+		for i, field := range first {
+			if field.value == column {
+				targets[i] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("column \"%s\" is not found", column)
+		}
+	}
+
+	return targets, nil
+}
+
+func (f *csvFormat) parse(s string) ([][]csvField, error) {
+
+	records := [][]csvField{}
+	line := 1
+	pos := 0
+	for pos < len(s) {
+
+		record := []csvField{}
+		for {
+			field, next, err := f.parseField(s, pos)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			record = append(record, field)
+			line += strings.Count(s[pos:next], "\n")
+			pos = next
+
+			if pos < len(s) && s[pos] == f.delimiter {
+				pos++
+				continue
+			}
+
+			// 行の終わり
+			if strings.HasPrefix(s[pos:], "\r\n") {
+				pos += 2
+			} else if pos < len(s) {
+				pos++
+			}
+			line++
+			break
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func (f *csvFormat) parseField(s string, start int) (csvField, int, error) {
+
+	if start < len(s) && s[start] == f.quote {
+		var builder strings.Builder
+		for i := start + 1; i < len(s); i++ {
+			if s[i] != f.quote {
+				builder.WriteByte(s[i])
+				continue
+			}
+
+			if i+1 < len(s) && s[i+1] == f.quote {
+				// 連続したクォートはエスケープされたクォート
+				builder.WriteByte(f.quote)
+				i++
+				continue
+			}
+
+			end := i + 1
+			if end < len(s) && s[end] != f.delimiter && s[end] != '\n' && !strings.HasPrefix(s[end:], "\r\n") {
+				return csvField{}, 0, fmt.Errorf("extraneous character after quoted field")
+			}
+
+			return csvField{start: start, end: end, value: builder.String(), quoted: true}, end, nil
+		}
+
+		return csvField{}, 0, fmt.Errorf("quoted field is not closed")
+	}
+
+	end := start
+	for end < len(s) && s[end] != f.delimiter && s[end] != '\n' {
+		end++
+	}
+
+	next := end
+	if end > start && s[end-1] == '\r' && end < len(s) && s[end] == '\n' {
+		end--
+	}
+
+	return csvField{start: start, end: end, value: s[start:end]}, next, nil
+}
+
+func (f *csvFormat) encode(value string, quoted bool) string {
+
+	quote := string(f.quote)
+	if quoted || strings.ContainsAny(value, string(f.delimiter)+quote+"\r\n") {
+		return quote + strings.ReplaceAll(value, quote, quote+quote) + quote
+	}
+
+	return value
+}
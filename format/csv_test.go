@@ -0,0 +1,126 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/onozaty/rcf/replace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSV_ColumnName(t *testing.T) {
+
+	format, err := NewCSV([]string{"url"}, ",", `"`, true)
+	require.NoError(t, err)
+
+	input := "name,url\r\nhttp://a,http://a\r\n\"x,y\",\"http://b\"\r\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("http:", "https:"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "name,url\r\nhttp://a,https://a\r\n\"x,y\",\"https://b\"\r\n", result)
+}
+
+func TestCSV_ColumnIndex(t *testing.T) {
+
+	format, err := NewCSV([]string{"2"}, ",", `"`, false)
+	require.NoError(t, err)
+
+	input := "a,a,a\na,a\na"
+	result, err := format.Replace(input, replace.NewStringReplacer("a", "x"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "a,x,a\na,x\na", result)
+}
+
+func TestCSV_AllColumns(t *testing.T) {
+
+	format, err := NewCSV([]string{}, ",", `"`, true)
+	require.NoError(t, err)
+
+	input := "a,b\na,a\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("a", "x"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "a,b\nx,x\n", result)
+}
+
+func TestCSV_Quote(t *testing.T) {
+
+	format, err := NewCSV([]string{"1"}, ",", `"`, false)
+	require.NoError(t, err)
+
+	// 置換後に区切り文字などを含む場合はクォートする
+	input := "a,a\n\"a\nb\",a\n\"a\"\"\",a\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("a", "\",\""))
+	require.NoError(t, err)
+
+	assert.Equal(t, "\"\"\",\"\"\",a\n\"\"\",\"\"\nb\",a\n\"\"\",\"\"\"\"\",a\n", result)
+}
+
+func TestCSV_DelimiterAndQuote(t *testing.T) {
+
+	format, err := NewCSV([]string{"b"}, "\t", "'", true)
+	require.NoError(t, err)
+
+	input := "a\tb\n'a\tb'\t'x''y'\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("x", "z"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "a\tb\n'a\tb'\t'z''y'\n", result)
+}
+
+func TestCSV_Empty(t *testing.T) {
+
+	format, err := NewCSV([]string{"x"}, ",", `"`, true)
+	require.NoError(t, err)
+
+	result, err := format.Replace("", replace.NewStringReplacer("a", "x"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "", result)
+}
+
+func TestCSV_ColumnNotFound(t *testing.T) {
+
+	format, err := NewCSV([]string{"x"}, ",", `"`, true)
+	require.NoError(t, err)
+
+	_, err = format.Replace("a,b\n", replace.NewStringReplacer("a", "x"))
+	assert.EqualError(t, err, `column "x" is not found`)
+}
+
+func TestCSV_ColumnNameWithoutHeader(t *testing.T) {
+
+	format, err := NewCSV([]string{"x"}, ",", `"`, false)
+	require.NoError(t, err)
+
+	_, err = format.Replace("a,b\n", replace.NewStringReplacer("a", "x"))
+	assert.EqualError(t, err, `column "x" requires a header`)
+}
+
+func TestCSV_Invalid(t *testing.T) {
+
+	format, err := NewCSV([]string{}, ",", `"`, false)
+	require.NoError(t, err)
+
+	{
+		_, err = format.Replace("a,b\n\"a,b\n", replace.NewStringReplacer("a", "x"))
+		assert.EqualError(t, err, "line 2: quoted field is not closed")
+	}
+	{
+		_, err = format.Replace("a,b\n\"a\"b\n", replace.NewStringReplacer("a", "x"))
+		assert.EqualError(t, err, "line 2: extraneous character after quoted field")
+	}
+}
+
+func TestNewCSV_Invalid(t *testing.T) {
+
+	{
+		_, err := NewCSV([]string{}, ",,", `"`, false)
+		assert.EqualError(t, err, `invalid delimiter ",,"`)
+	}
+	{
+		_, err := NewCSV([]string{}, ",", ",", false)
+		assert.EqualError(t, err, `invalid quote ","`)
+	}
+}
@@ -0,0 +1,160 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onozaty/rcf/replace"
+)
+
+type dotenvFormat struct {
+	keys []string
+}
+
+// .envファイル (KEY=value の形式で、先頭にexportがあっても良い)
+// keysが指定された場合は、そのキーの値のみが対象
+func NewDotenv(keys []string) (Format, error) {
+
+	return &dotenvFormat{
+		keys: keys,
+	}, nil
+}
+
+func (f *dotenvFormat) Replace(s string, replacer replace.Replacer) (string, error) {
+
+	edits := []edit{}
+	pos := 0
+	for pos < len(s) {
+
+		lineStart := pos
+		lineEnd := strings.IndexByte(s[pos:], '\n')
+		if lineEnd == -1 {
+			lineEnd = len(s)
+		} else {
+			lineEnd += pos
+		}
+		pos = lineEnd + 1
+
+		start := skipDotenvSpaces(s, lineStart)
+		if strings.HasPrefix(s[start:lineEnd], "export") && start+len("export") < lineEnd && isDotenvSpace(s[start+len("export")]) {
+			start = skipDotenvSpaces(s, start+len("export"))
+		}
+
+		separator := strings.IndexByte(s[start:lineEnd], '=')
+		if start == lineEnd || s[start] == '#' || separator == -1 {
+			continue
+		}
+
+		key := strings.TrimSpace(s[start : start+separator])
+		valueStart := skipDotenvSpaces(s, start+separator+1)
+		target := len(f.keys) == 0 || contains(f.keys, key)
+
+		if valueStart < len(s) && (s[valueStart] == '"' || s[valueStart] == '\'') {
+			// クォートで囲まれた値は複数行になることもある
+			quote := s[valueStart]
+			valueEnd := closingDotenvQuote(s, valueStart)
+			if valueEnd == -1 {
+				return "", fmt.Errorf("line %d: quoted value is not closed", strings.Count(s[:lineStart], "\n")+1)
+			}
+
+			next := strings.IndexByte(s[valueEnd:], '\n')
+			if next == -1 {
+				pos = len(s)
+			} else {
+				pos = valueEnd + next + 1
+			}
+
+			if !target {
+				continue
+			}
+
+			raw := s[valueStart+1 : valueEnd]
+			value := raw
+			if quote == '"' {
+				value = unescapeDotenv(raw)
+			}
+
+			replaced := replacer.Replace(value)
+			if replaced == value {
+				continue
+			}
+
+			text := "'" + replaced + "'"
+			if quote == '"' || strings.Contains(replaced, "'") {
+				text = quoteDotenv(replaced)
+			}
+			edits = append(edits, edit{start: valueStart, end: valueEnd + 1, text: text})
+			continue
+		}
+
+		if !target {
+			continue
+		}
+
+		// クォートされていない値は、空白に続く#以降がコメント
+		valueEnd := lineEnd
+		for i := valueStart; i < lineEnd; i++ {
+			if s[i] == '#' && i > valueStart && isDotenvSpace(s[i-1]) {
+				valueEnd = i
+				break
+			}
+		}
+		for valueEnd > valueStart && (isDotenvSpace(s[valueEnd-1]) || s[valueEnd-1] == '\r') {
+			valueEnd--
+		}
+
+		value := s[valueStart:valueEnd]
+		replaced := replacer.Replace(value)
+		if replaced == value {
+			continue
+		}
+
+		text := replaced
+		if strings.ContainsAny(replaced, " \t\r\n#'\"\\") {
+			text = quoteDotenv(replaced)
+		}
+		edits = append(edits, edit{start: valueStart, end: valueEnd, text: text})
+	}
+
+	return applyEdits(s, edits), nil
+}
+
+func isDotenvSpace(b byte) bool {
+	return b == ' ' || b == '\t'
+}
+
+func skipDotenvSpaces(s string, pos int) int {
+
+	for pos < len(s) && isDotenvSpace(s[pos]) {
+		pos++
+	}
+
+	return pos
+}
+
+func closingDotenvQuote(s string, start int) int {
+
+	quote := s[start]
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+func unescapeDotenv(s string) string {
+
+	return strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(s)
+}
+
+func quoteDotenv(s string) string {
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`).Replace(s) + `"`
+}
@@ -0,0 +1,67 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/onozaty/rcf/replace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDotenv_Key(t *testing.T) {
+
+	format, err := NewDotenv([]string{"API_URL"})
+	require.NoError(t, err)
+
+	input := "# API_URL=http://a\nAPI_URL=http://a # comment\nexport API_URL = http://a\nOTHER_URL=http://a\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("http:", "https:"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "# API_URL=http://a\nAPI_URL=https://a # comment\nexport API_URL = https://a\nOTHER_URL=http://a\n", result)
+}
+
+func TestDotenv_Quoted(t *testing.T) {
+
+	format, err := NewDotenv([]string{})
+	require.NoError(t, err)
+
+	input := "A=\"x\\n\\\"x\\\"\" # x\r\nB='x\\n'\r\nC='x'\r\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("x", "y'"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "A=\"y'\\n\\\"y'\\\"\" # x\r\nB=\"y'\\\\n\"\r\nC=\"y'\"\r\n", result)
+}
+
+func TestDotenv_Multiline(t *testing.T) {
+
+	format, err := NewDotenv([]string{"KEY"})
+	require.NoError(t, err)
+
+	input := "KEY=\"-----BEGIN-----\nabc=\n-----END-----\"\nabc=abc\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("abc", "xyz"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "KEY=\"-----BEGIN-----\\nxyz=\\n-----END-----\"\nabc=abc\n", result)
+}
+
+func TestDotenv_QuoteIfNeeded(t *testing.T) {
+
+	format, err := NewDotenv([]string{})
+	require.NoError(t, err)
+
+	input := "A=x\nB=x#x\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("x", "a #b"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "A=\"a #b\"\nB=\"a #b#a #b\"\n", result)
+}
+
+func TestDotenv_NotClosed(t *testing.T) {
+
+	format, err := NewDotenv([]string{})
+	require.NoError(t, err)
+
+	_, err = format.Replace("A=a\nB=\"a\n", replace.NewStringReplacer("a", "b"))
+	require.Error(t, err)
+	assert.Equal(t, "line 2: quoted value is not closed", err.Error())
+}
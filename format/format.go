@@ -0,0 +1,32 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/onozaty/rcf/replace"
+)
+
+// 文書の構造を解析し、対象となる値に対してのみReplacerを適用
+type Format interface {
+	Replace(string, replace.Replacer) (string, error)
+}
+
+type edit struct {
+	start int
+	end   int
+	text  string
+}
+
+func applyEdits(s string, edits []edit) string {
+
+	var builder strings.Builder
+	last := 0
+	for _, e := range edits {
+		builder.WriteString(s[last:e.start])
+		builder.WriteString(e.text)
+		last = e.end
+	}
+	builder.WriteString(s[last:])
+
+	return builder.String()
+}
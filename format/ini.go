@@ -0,0 +1,98 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/onozaty/rcf/replace"
+)
+
+type iniFormat struct {
+	sections []string
+	keys     []string
+}
+
+// sectionsやkeysが指定された場合は、そのセクションやキーの値のみが対象
+// 最初のセクションより前に書かれたキーは、空文字のセクションとして扱う
+func NewINI(sections []string, keys []string) (Format, error) {
+
+	return &iniFormat{
+		sections: sections,
+		keys:     keys,
+	}, nil
+}
+
+func (f *iniFormat) Replace(s string, replacer replace.Replacer) (string, error) {
+
+	edits := []edit{}
+	section := ""
+	pos := 0
+	for pos < len(s) {
+
+		lineEnd := strings.IndexByte(s[pos:], '\n')
+		next := len(s)
+		if lineEnd == -1 {
+			lineEnd = len(s)
+		} else {
+			lineEnd += pos
+			next = lineEnd + 1
+		}
+
+		start := pos
+		end := lineEnd
+		pos = next
+
+		for start < end && isINISpace(s[start]) {
+			start++
+		}
+		for end > start && (isINISpace(s[end-1]) || s[end-1] == '\r') {
+			end--
+		}
+		line := s[start:end]
+
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if close := strings.IndexByte(line, ']'); close != -1 {
+				section = strings.TrimSpace(line[1:close])
+			}
+			continue
+		}
+
+		separator := strings.IndexAny(line, "=:")
+		if separator == -1 {
+			// 値の無いキー
+			continue
+		}
+
+		key := strings.TrimSpace(line[:separator])
+		if (len(f.sections) != 0 && !contains(f.sections, section)) || (len(f.keys) != 0 && !contains(f.keys, key)) {
+			continue
+		}
+
+		valueStart := start + separator + 1
+		for valueStart < end && isINISpace(s[valueStart]) {
+			valueStart++
+		}
+		valueEnd := end
+
+		// クォートで囲まれている場合は、その内側を値とする
+		if valueEnd-valueStart >= 2 && (s[valueStart] == '"' || s[valueStart] == '\'') && s[valueEnd-1] == s[valueStart] {
+			valueStart++
+			valueEnd--
+		}
+
+		value := s[valueStart:valueEnd]
+		replaced := replacer.Replace(value)
+		if replaced != value {
+			edits = append(edits, edit{start: valueStart, end: valueEnd, text: replaced})
+		}
+	}
+
+	return applyEdits(s, edits), nil
+}
+
+func isINISpace(b byte) bool {
+	return b == ' ' || b == '\t'
+}
@@ -0,0 +1,60 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/onozaty/rcf/replace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestINI_Section(t *testing.T) {
+
+	format, err := NewINI([]string{"database"}, []string{"host"})
+	require.NoError(t, err)
+
+	input := "host=localhost\r\n; host=localhost\r\n[database]\r\nhost = localhost\r\nname=localhost\r\n[ cache ]\r\nhost=localhost\r\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("localhost", "db.example.com"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "host=localhost\r\n; host=localhost\r\n[database]\r\nhost = db.example.com\r\nname=localhost\r\n[ cache ]\r\nhost=localhost\r\n", result)
+}
+
+func TestINI_GlobalSection(t *testing.T) {
+
+	format, err := NewINI([]string{""}, []string{})
+	require.NoError(t, err)
+
+	input := "a=x\nb: x\n[s]\na=x\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("x", "y"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "a=y\nb: y\n[s]\na=x\n", result)
+}
+
+func TestINI_Quoted(t *testing.T) {
+
+	format, err := NewINI([]string{}, []string{})
+	require.NoError(t, err)
+
+	input := "a = \"x x\"  \nb='x'\nc=\"x\n# d=x\nflag\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("x", "y"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "a = \"y y\"  \nb='y'\nc=\"y\n# d=x\nflag\n", result)
+}
+
+func TestINI_EmptyValue(t *testing.T) {
+
+	format, err := NewINI([]string{}, []string{"a"})
+	require.NoError(t, err)
+
+	replacer, err := replace.NewRegexpReplacer("^.*$", "value")
+	require.NoError(t, err)
+
+	input := "[s]\na =\nb=\n"
+	result, err := format.Replace(input, replacer)
+	require.NoError(t, err)
+
+	assert.Equal(t, "[s]\na =value\nb=\n", result)
+}
@@ -0,0 +1,132 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/onozaty/rcf/replace"
+)
+
+type jsonFormat struct {
+	path *Path
+}
+
+func NewJSON(pathStr string) (Format, error) {
+
+	path, err := ParsePath(pathStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonFormat{
+		path: path,
+	}, nil
+}
+
+type jsonFrame struct {
+	object    bool
+	key       string
+	index     int
+	expectKey bool
+}
+
+func (f *jsonFormat) Replace(s string, replacer replace.Replacer) (string, error) {
+
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+
+	edits := []edit{}
+	stack := []*jsonFrame{}
+
+	// 値を読み終えたら、親の状態を次へ進める
+	completeValue := func() {
+		if len(stack) == 0 {
+			return
+		}
+		parent := stack[len(stack)-1]
+		if parent.object {
+			parent.expectKey = true
+		} else {
+			parent.index++
+		}
+	}
+
+	for {
+		prevOffset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			if len(stack) != 0 {
+				return "", io.ErrUnexpectedEOF
+			}
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if delim, ok := token.(json.Delim); ok {
+			switch delim {
+			case '{':
+				stack = append(stack, &jsonFrame{object: true, expectKey: true})
+			case '[':
+				stack = append(stack, &jsonFrame{object: false})
+			case '}', ']':
+				stack = stack[:len(stack)-1]
+				completeValue()
+			}
+			continue
+		}
+
+		if len(stack) != 0 && stack[len(stack)-1].object && stack[len(stack)-1].expectKey {
+			// オブジェクトのキー
+			stack[len(stack)-1].key = token.(string)
+			stack[len(stack)-1].expectKey = false
+			continue
+		}
+
+		if value, ok := token.(string); ok && f.path.match(jsonLocation(stack)) {
+			replaced := replacer.Replace(value)
+			if replaced != value {
+				// 区切り文字や空白を読み飛ばした位置が値の開始位置
+				start := prevOffset + strings.Index(s[prevOffset:], `"`)
+				encoded, err := encodeJSONString(replaced)
+				if err != nil {
+					return "", err
+				}
+				edits = append(edits, edit{start: start, end: int(decoder.InputOffset()), text: encoded})
+			}
+		}
+
+		completeValue()
+	}
+
+	return applyEdits(s, edits), nil
+}
+
+func jsonLocation(stack []*jsonFrame) []step {
+
+	location := make([]step, len(stack))
+	for i, frame := range stack {
+		if frame.object {
+			location[i] = step{kind: keyStep, key: frame.key}
+		} else {
+			location[i] = step{kind: indexStep, index: frame.index}
+		}
+	}
+
+	return location
+}
+
+func encodeJSONString(s string) (string, error) {
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -0,0 +1,92 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/onozaty/rcf/replace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON(t *testing.T) {
+
+	format, err := NewJSON("$.services.*.image")
+	require.NoError(t, err)
+
+	replacer, err := replace.NewRegexpReplacer(":.*$", ":2.0")
+	require.NoError(t, err)
+
+	input := `{
+  "services": {
+    "web": { "image": "web:1.0", "ports": [80] },
+    "db":  {"image"  :  "db:1.0"},
+    "image": "x:1.0"
+  },
+  "image": "top:1.0"
+}
+`
+	result, err := format.Replace(input, replacer)
+	require.NoError(t, err)
+
+	assert.Equal(t, `{
+  "services": {
+    "web": { "image": "web:2.0", "ports": [80] },
+    "db":  {"image"  :  "db:2.0"},
+    "image": "x:1.0"
+  },
+  "image": "top:1.0"
+}
+`, result)
+}
+
+func TestJSON_Array(t *testing.T) {
+
+	format, err := NewJSON("$[1].name")
+	require.NoError(t, err)
+
+	result, err := format.Replace(`[{"name":"a"},{"name":"a","x":[{"name":"a"}]},{"name":"a"}]`, replace.NewStringReplacer("a", "b"))
+	require.NoError(t, err)
+
+	assert.Equal(t, `[{"name":"a"},{"name":"b","x":[{"name":"a"}]},{"name":"a"}]`, result)
+}
+
+func TestJSON_Escape(t *testing.T) {
+
+	format, err := NewJSON("$.*")
+	require.NoError(t, err)
+
+	result, err := format.Replace(`{"a":"x\"y","b":"あ","c":1,"d":"<>"}`, replace.NewStringReplacer("x", "\"\n"))
+	require.NoError(t, err)
+
+	// 変更が無い値は元の記述のまま
+	assert.Equal(t, `{"a":"\"\n\"y","b":"あ","c":1,"d":"<>"}`, result)
+}
+
+func TestJSON_Lines(t *testing.T) {
+
+	format, err := NewJSON("$.a")
+	require.NoError(t, err)
+
+	result, err := format.Replace("{\"a\":\"x\"}\n{\"a\":\"x\"}\n", replace.NewStringReplacer("x", "y"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "{\"a\":\"y\"}\n{\"a\":\"y\"}\n", result)
+}
+
+func TestJSON_Invalid(t *testing.T) {
+
+	format, err := NewJSON("$.a")
+	require.NoError(t, err)
+
+	_, err = format.Replace(`{"a":}`, replace.NewStringReplacer("x", "y"))
+	assert.Error(t, err)
+}
+
+func TestJSON_UnexpectedEOF(t *testing.T) {
+
+	format, err := NewJSON("$.a")
+	require.NoError(t, err)
+
+	_, err = format.Replace(`{"a":`, replace.NewStringReplacer("x", "y"))
+	assert.EqualError(t, err, "unexpected EOF")
+}
@@ -0,0 +1,458 @@
+package format
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/onozaty/rcf/replace"
+)
+
+type markupFormat struct {
+	html     bool
+	selector *Selector
+	attrs    []string
+}
+
+// 属性の指定が無い場合はテキストノード、指定がある場合はその属性の値が対象
+// セレクタが指定された場合は、マッチした要素(テキストノードの場合はその子孫も含む)のみが対象
+func NewXML(selector string, attrs []string) (Format, error) {
+
+	return newMarkup(false, selector, attrs)
+}
+
+func NewHTML(selector string, attrs []string) (Format, error) {
+
+	return newMarkup(true, selector, attrs)
+}
+
+func newMarkup(isHTML bool, selectorStr string, attrs []string) (Format, error) {
+
+	var selector *Selector
+	if selectorStr != "" {
+		s, err := ParseSelector(selectorStr)
+		if err != nil {
+			return nil, err
+		}
+		selector = s
+	}
+
+	lowerAttrs := make([]string, len(attrs))
+	for i, attr := range attrs {
+		lowerAttrs[i] = strings.ToLower(attr)
+	}
+
+	return &markupFormat{
+		html:     isHTML,
+		selector: selector,
+		attrs:    lowerAttrs,
+	}, nil
+}
+
+// 子要素を持たないHTMLの要素
+var voidElements = []string{"area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "param", "source", "track", "wbr"}
+
+// 内容をテキストとして解釈しないHTMLの要素
+var rawTextElements = []string{"script", "style"}
+
+type attribute struct {
+	name   string
+	value  string
+	raw    string // 文字参照をデコードする前の値
+	start  int
+	end    int
+	quoted bool
+}
+
+func (f *markupFormat) Replace(s string, replacer replace.Replacer) (string, error) {
+
+	edits := []edit{}
+	stack := []element{}
+
+	// スタック上のいずれかの要素がセレクタにマッチすれば、その子孫のテキストも対象
+	inSelected := func() bool {
+		if f.selector == nil {
+			return true
+		}
+		for i := len(stack); i > 0; i-- {
+			if f.selector.match(stack[:i]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	replaceText := func(start int, end int) {
+		if len(f.attrs) != 0 || !inSelected() {
+			return
+		}
+		text := decodeMarkupText(s[start:end])
+		value := text.value()
+		replaced := replacer.Replace(value)
+		if replaced != value {
+			edits = append(edits, edit{start: start, end: end, text: text.encode(replaced, escapeText)})
+		}
+	}
+
+	pos := 0
+	for pos < len(s) {
+
+		next := strings.IndexByte(s[pos:], '<')
+		if next == -1 {
+			replaceText(pos, len(s))
+			break
+		}
+		next += pos
+		if next > pos {
+			replaceText(pos, next)
+		}
+		pos = next
+		rest := s[pos:]
+
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest, "-->")
+			if end == -1 {
+				return "", fmt.Errorf("comment is not closed")
+			}
+			pos += end + len("-->")
+
+		case strings.HasPrefix(rest, "<![CDATA["):
+			end := strings.Index(rest, "]]>")
+			if end == -1 {
+				return "", fmt.Errorf("CDATA section is not closed")
+			}
+			if len(f.attrs) == 0 && inSelected() {
+				value := rest[len("<![CDATA["):end]
+				replaced := replacer.Replace(value)
+				if replaced != value {
+					start := pos + len("<![CDATA[")
+					edits = append(edits, edit{start: start, end: pos + end, text: strings.ReplaceAll(replaced, "]]>", "]]]]><![CDATA[>")})
+				}
+			}
+			pos += end + len("]]>")
+
+		case strings.HasPrefix(rest, "<?"):
+			end := strings.Index(rest, "?>")
+			if end == -1 {
+				return "", fmt.Errorf("processing instruction is not closed")
+			}
+			pos += end + len("?>")
+
+		case strings.HasPrefix(rest, "<!"):
+			end := strings.IndexByte(rest, '>')
+			if end == -1 {
+				return "", fmt.Errorf("declaration is not closed")
+			}
+			pos += end + 1
+
+		case strings.HasPrefix(rest, "</"):
+			end := strings.IndexByte(rest, '>')
+			if end == -1 {
+				return "", fmt.Errorf("end tag is not closed")
+			}
+			name := strings.TrimSpace(rest[2:end])
+			// 対応する開始タグまで閉じる (見つからない場合は無視)
+			for i := len(stack) - 1; i >= 0; i-- {
+				if strings.EqualFold(stack[i].name, name) {
+					stack = stack[:i]
+					break
+				}
+			}
+			pos += end + 1
+
+		default:
+			name, attrs, end, selfClosing, ok := parseStartTag(s, pos)
+			if !ok {
+				// タグとして解釈できない < はテキストとして扱う
+				textEnd := strings.IndexByte(s[pos+1:], '<')
+				if textEnd == -1 {
+					textEnd = len(s)
+				} else {
+					textEnd += pos + 1
+				}
+				replaceText(pos, textEnd)
+				pos = textEnd
+				continue
+			}
+
+			e := element{name: name, attrs: map[string]string{}}
+			for _, attr := range attrs {
+				e.attrs[attr.name] = attr.value
+			}
+			stack = append(stack, e)
+
+			if len(f.attrs) != 0 && (f.selector == nil || f.selector.match(stack)) {
+				for _, attr := range attrs {
+					if !contains(f.attrs, attr.name) || attr.start == attr.end {
+						// 値が無い属性は対象外
+						continue
+					}
+					text := decodeMarkupText(attr.raw)
+					value := text.value()
+					replaced := replacer.Replace(value)
+					if replaced != value {
+						quote := s[attr.start]
+						if !attr.quoted {
+							quote = '"'
+						}
+						escaped := text.encode(replaced, func(v string) string {
+							return escapeAttribute(v, quote)
+						})
+						edits = append(edits, edit{start: attr.start, end: attr.end, text: string(quote) + escaped + string(quote)})
+					}
+				}
+			}
+
+			pos = end
+
+			if f.html && contains(rawTextElements, strings.ToLower(name)) && !selfClosing {
+				// scriptやstyleの内容は対象外
+				closeTag := strings.Index(strings.ToLower(s[pos:]), "</"+strings.ToLower(name))
+				if closeTag == -1 {
+					pos = len(s)
+				} else {
+					pos += closeTag
+				}
+			}
+
+			if selfClosing || (f.html && contains(voidElements, strings.ToLower(name))) {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	return applyEdits(s, edits), nil
+}
+
+func parseStartTag(s string, start int) (string, []attribute, int, bool, bool) {
+
+	pos := start + 1
+	nameEnd := pos
+	for nameEnd < len(s) && !isMarkupSpace(s[nameEnd]) && s[nameEnd] != '>' && s[nameEnd] != '/' {
+		nameEnd++
+	}
+	name := s[pos:nameEnd]
+	if name == "" || !isNameStart(name[0]) {
+		return "", nil, 0, false, false
+	}
+	pos = nameEnd
+
+	attrs := []attribute{}
+	for {
+		for pos < len(s) && isMarkupSpace(s[pos]) {
+			pos++
+		}
+		if pos >= len(s) {
+			return "", nil, 0, false, false
+		}
+
+		if s[pos] == '>' {
+			return name, attrs, pos + 1, false, true
+		}
+		if strings.HasPrefix(s[pos:], "/>") {
+			return name, attrs, pos + 2, true, true
+		}
+		if s[pos] == '/' {
+			pos++
+			continue
+		}
+
+		attrStart := pos
+		for pos < len(s) && !isMarkupSpace(s[pos]) && s[pos] != '=' && s[pos] != '>' && !strings.HasPrefix(s[pos:], "/>") {
+			pos++
+		}
+		attr := attribute{name: strings.ToLower(s[attrStart:pos])}
+
+		for pos < len(s) && isMarkupSpace(s[pos]) {
+			pos++
+		}
+		if pos >= len(s) || s[pos] != '=' {
+			// 値の無い属性
+			attr.start, attr.end = pos, pos
+			attrs = append(attrs, attr)
+			continue
+		}
+		pos++
+		for pos < len(s) && isMarkupSpace(s[pos]) {
+			pos++
+		}
+		if pos >= len(s) {
+			return "", nil, 0, false, false
+		}
+
+		if s[pos] == '"' || s[pos] == '\'' {
+			end := strings.IndexByte(s[pos+1:], s[pos])
+			if end == -1 {
+				return "", nil, 0, false, false
+			}
+			attr.start = pos
+			attr.end = pos + 1 + end + 1
+			attr.raw = s[pos+1 : pos+1+end]
+			attr.value = html.UnescapeString(attr.raw)
+			attr.quoted = true
+			pos = attr.end
+		} else {
+			valueStart := pos
+			for pos < len(s) && !isMarkupSpace(s[pos]) && s[pos] != '>' {
+				pos++
+			}
+			attr.start = valueStart
+			attr.end = pos
+			attr.raw = s[valueStart:pos]
+			attr.value = html.UnescapeString(attr.raw)
+		}
+
+		attrs = append(attrs, attr)
+	}
+}
+
+func isMarkupSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
+
+func isNameStart(b byte) bool {
+	return b == '_' || b == ':' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || b >= 0x80
+}
+
+func escapeText(s string) string {
+
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func escapeAttribute(s string, quote byte) string {
+
+	escaped := strings.NewReplacer("&", "&amp;", "<", "&lt;").Replace(s)
+	if quote == '"' {
+		return strings.ReplaceAll(escaped, `"`, "&quot;")
+	}
+
+	return strings.ReplaceAll(escaped, "'", "&#39;")
+}
+
+// 文字参照を含むテキスト
+// 置換で変わらなかった部分は元の記述のままとし、文字参照をできるだけ維持する
+type markupText []markupUnit
+
+type markupUnit struct {
+	value string
+	raw   string // 文字参照の場合のみ
+}
+
+func decodeMarkupText(raw string) markupText {
+
+	text := markupText{}
+	last := 0
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '&' {
+			continue
+		}
+
+		end := i + 1
+		for end < len(raw) && isReferenceChar(raw[end]) {
+			end++
+		}
+		if end < len(raw) && raw[end] == ';' {
+			end++
+		}
+
+		reference := raw[i:end]
+		value := html.UnescapeString(reference)
+		if value == reference {
+			// 文字参照ではない
+			continue
+		}
+
+		if last < i {
+			text = append(text, markupUnit{value: raw[last:i]})
+		}
+		text = append(text, markupUnit{value: value, raw: reference})
+		last = end
+		i = end - 1
+	}
+	if last < len(raw) {
+		text = append(text, markupUnit{value: raw[last:]})
+	}
+
+	return text
+}
+
+func isReferenceChar(b byte) bool {
+	return b == '#' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
+
+func (t markupText) value() string {
+
+	var builder strings.Builder
+	for _, unit := range t {
+		builder.WriteString(unit.value)
+	}
+
+	return builder.String()
+}
+
+func (t markupText) encode(replaced string, escape func(string) string) string {
+
+	value := t.value()
+
+	// 前後の変わらなかった部分
+	prefix := 0
+	for prefix < len(value) && prefix < len(replaced) && value[prefix] == replaced[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(value)-prefix && suffix < len(replaced)-prefix && value[len(value)-1-suffix] == replaced[len(replaced)-1-suffix] {
+		suffix++
+	}
+
+	// 単位の途中で区切らないように、前後それぞれ単位全体が変わらなかったものだけを元のままとする
+	head := 0
+	headLength := 0
+	for head < len(t) && headLength+len(t[head].value) <= prefix {
+		headLength += len(t[head].value)
+		head++
+	}
+	tail := len(t)
+	tailLength := 0
+	for tail > head && tailLength+len(t[tail-1].value) <= suffix {
+		tailLength += len(t[tail-1].value)
+		tail--
+	}
+
+	// 変わった部分も、元々文字参照で書かれていた文字は同じ文字参照で書く
+	references := map[rune]string{}
+	for _, unit := range t {
+		if unit.raw == "" {
+			continue
+		}
+		runes := []rune(unit.value)
+		if _, ok := references[runes[0]]; len(runes) == 1 && !ok {
+			references[runes[0]] = unit.raw
+		}
+	}
+
+	var builder strings.Builder
+	for _, unit := range t[:head] {
+		builder.WriteString(unit.source())
+	}
+	for _, r := range replaced[headLength : len(replaced)-tailLength] {
+		if reference, ok := references[r]; ok {
+			builder.WriteString(reference)
+		} else {
+			builder.WriteString(escape(string(r)))
+		}
+	}
+	for _, unit := range t[tail:] {
+		builder.WriteString(unit.source())
+	}
+
+	return builder.String()
+}
+
+func (u markupUnit) source() string {
+
+	if u.raw != "" {
+		return u.raw
+	}
+	return u.value
+}
@@ -0,0 +1,167 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/onozaty/rcf/replace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTML_Text(t *testing.T) {
+
+	format, err := NewHTML("", []string{})
+	require.NoError(t, err)
+
+	input := `<!DOCTYPE html>
+<html lang="a">
+<head><title>a &amp; b</title><style>a { color: red; }</style></head>
+<body class="a">
+<!-- a -->
+<p>a<br>a</p>
+<script>var a = "<a>";</script>
+</body>
+</html>
+`
+	result, err := format.Replace(input, replace.NewStringReplacer("a", "<x>"))
+	require.NoError(t, err)
+
+	assert.Equal(t, `<!DOCTYPE html>
+<html lang="a">
+<head><title>&lt;x&gt; &amp; b</title><style>a { color: red; }</style></head>
+<body class="a">
+<!-- a -->
+<p>&lt;x&gt;<br>&lt;x&gt;</p>
+<script>var a = "<a>";</script>
+</body>
+</html>
+`, result)
+}
+
+func TestHTML_Selector(t *testing.T) {
+
+	format, err := NewHTML("div.target", []string{})
+	require.NoError(t, err)
+
+	input := `<div>a</div><div class="target">a<p>a</p></div><p>a</p>`
+	result, err := format.Replace(input, replace.NewStringReplacer("a", "x"))
+	require.NoError(t, err)
+
+	assert.Equal(t, `<div>a</div><div class="target">x<p>x</p></div><p>a</p>`, result)
+}
+
+func TestHTML_Attribute(t *testing.T) {
+
+	format, err := NewHTML("a", []string{"href"})
+	require.NoError(t, err)
+
+	input := `<a href="http://a/?x=1&amp;y=2">http://a/</a><a HREF='http://a/' disabled><img src="http://a/"><a href=http://a/>`
+	result, err := format.Replace(input, replace.NewStringReplacer("http:", "https:"))
+	require.NoError(t, err)
+
+	assert.Equal(t, `<a href="https://a/?x=1&amp;y=2">http://a/</a><a HREF='https://a/' disabled><img src="http://a/"><a href="https://a/">`, result)
+}
+
+func TestHTML_AttributeQuote(t *testing.T) {
+
+	format, err := NewHTML("", []string{"title"})
+	require.NoError(t, err)
+
+	input := `<p title="a">a</p><p title='a'>a</p>`
+	result, err := format.Replace(input, replace.NewStringReplacer("a", `"'&`))
+	require.NoError(t, err)
+
+	assert.Equal(t, `<p title="&quot;'&amp;">a</p><p title='"&#39;&amp;'>a</p>`, result)
+}
+
+func TestHTML_KeepReferences(t *testing.T) {
+
+	format, err := NewHTML("", []string{})
+	require.NoError(t, err)
+
+	// 置換した箇所以外の文字参照はそのまま
+	input := `<p>&copy; 2020&nbsp;Example &#x41;</p><p>2020 &copy; 2020</p>`
+	result, err := format.Replace(input, replace.NewStringReplacer("2020", "2021"))
+	require.NoError(t, err)
+
+	assert.Equal(t, `<p>&copy; 2021&nbsp;Example &#x41;</p><p>2021 &copy; 2021</p>`, result)
+}
+
+func TestHTML_KeepReferences_Attribute(t *testing.T) {
+
+	format, err := NewHTML("", []string{"title"})
+	require.NoError(t, err)
+
+	input := `<p title="&copy; 2020 &amp; 2020">a</p>`
+	result, err := format.Replace(input, replace.NewStringReplacer("2020", "2021"))
+	require.NoError(t, err)
+
+	assert.Equal(t, `<p title="&copy; 2021 &amp; 2021">a</p>`, result)
+}
+
+func TestHTML_ReplaceReference(t *testing.T) {
+
+	format, err := NewHTML("", []string{})
+	require.NoError(t, err)
+
+	// 文字参照そのものを対象とすることもできる
+	input := `<p>AT&amp;T &copy;</p>`
+	result, err := format.Replace(input, replace.NewStringReplacer("AT&T ©", "X"))
+	require.NoError(t, err)
+
+	assert.Equal(t, `<p>X</p>`, result)
+}
+
+func TestXML(t *testing.T) {
+
+	format, err := NewXML("item > name", []string{})
+	require.NoError(t, err)
+
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<items>
+  <item><name>a</name><value>a</value></item>
+  <item><name><![CDATA[a<b]]></name></item>
+  <name>a</name>
+  <item><name/></item>
+</items>
+`
+	result, err := format.Replace(input, replace.NewStringReplacer("a", "x"))
+	require.NoError(t, err)
+
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<items>
+  <item><name>x</name><value>a</value></item>
+  <item><name><![CDATA[x<b]]></name></item>
+  <name>a</name>
+  <item><name/></item>
+</items>
+`, result)
+}
+
+func TestXML_Text(t *testing.T) {
+
+	format, err := NewXML("", []string{})
+	require.NoError(t, err)
+
+	// タグとして解釈できない<はテキストとして扱う
+	input := `<a>1 < 2 &lt; 3</a>`
+	result, err := format.Replace(input, replace.NewStringReplacer("2", "0"))
+	require.NoError(t, err)
+
+	assert.Equal(t, `<a>1 &lt; 0 &lt; 3</a>`, result)
+}
+
+func TestXML_Invalid(t *testing.T) {
+
+	format, err := NewXML("", []string{})
+	require.NoError(t, err)
+
+	{
+		_, err := format.Replace("<a><!-- a</a>", replace.NewStringReplacer("a", "x"))
+		assert.EqualError(t, err, "comment is not closed")
+	}
+	{
+		_, err := format.Replace("<a><![CDATA[a</a>", replace.NewStringReplacer("a", "x"))
+		assert.EqualError(t, err, "CDATA section is not closed")
+	}
+}
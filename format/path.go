@@ -0,0 +1,134 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	keyStep stepKind = iota
+	indexStep
+	wildcardStep
+	// 0個以上の任意の階層
+	descendantStep
+)
+
+type step struct {
+	kind  stepKind
+	key   string
+	index int
+}
+
+// 値の位置を表すパス
+// $.services.*.image、$.items[0].name、$['a.b']、$..image のような形式
+type Path struct {
+	steps []step
+}
+
+func ParsePath(str string) (*Path, error) {
+
+	s := strings.TrimPrefix(str, "$")
+	steps := []step{}
+
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			if strings.HasPrefix(s, ".") {
+				steps = append(steps, step{kind: descendantStep})
+				s = s[1:]
+				if strings.HasPrefix(s, "[") {
+					continue
+				}
+			}
+			end := strings.IndexAny(s, ".[")
+			if end == -1 {
+				end = len(s)
+			}
+			name := s[:end]
+			if name == "" {
+				return nil, fmt.Errorf("invalid path \"%s\"", str)
+			}
+			if name == "*" {
+				steps = append(steps, step{kind: wildcardStep})
+			} else {
+				steps = append(steps, step{kind: keyStep, key: name})
+			}
+			s = s[end:]
+
+		case '[':
+			end := strings.Index(s, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid path \"%s\"", str)
+			}
+			inner := s[1:end]
+			s = s[end+1:]
+
+			if inner == "*" {
+				steps = append(steps, step{kind: wildcardStep})
+			} else if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, step{kind: keyStep, key: inner[1 : len(inner)-1]})
+			} else if index, err := strconv.Atoi(inner); err == nil && index >= 0 {
+				steps = append(steps, step{kind: indexStep, index: index})
+			} else {
+				return nil, fmt.Errorf("invalid path \"%s\"", str)
+			}
+
+		default:
+			if len(steps) == 0 && !strings.HasPrefix(str, "$") {
+				// 先頭の$と.は省略可能
+				s = "." + s
+				continue
+			}
+			return nil, fmt.Errorf("invalid path \"%s\"", str)
+		}
+	}
+
+	return &Path{
+		steps: steps,
+	}, nil
+}
+
+func (p *Path) match(location []step) bool {
+
+	return matchSteps(p.steps, location)
+}
+
+func matchSteps(steps []step, location []step) bool {
+
+	if len(steps) == 0 {
+		return len(location) == 0
+	}
+
+	s := steps[0]
+	if s.kind == descendantStep {
+		// 任意の階層を読み飛ばした位置で、残りがマッチするか
+		for i := 0; i <= len(location); i++ {
+			if matchSteps(steps[1:], location[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(location) == 0 {
+		return false
+	}
+
+	l := location[0]
+	switch s.kind {
+	case keyStep:
+		if l.kind != keyStep || l.key != s.key {
+			return false
+		}
+	case indexStep:
+		if l.kind != indexStep || l.index != s.index {
+			return false
+		}
+	}
+
+	return matchSteps(steps[1:], location[1:])
+}
@@ -0,0 +1,91 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePath(t *testing.T) {
+
+	{
+		path, err := ParsePath("$.services.*.image")
+		require.NoError(t, err)
+		assert.Equal(t, []step{{kind: keyStep, key: "services"}, {kind: wildcardStep}, {kind: keyStep, key: "image"}}, path.steps)
+	}
+	{
+		path, err := ParsePath("$.items[0]['a.b'][*]")
+		require.NoError(t, err)
+		assert.Equal(t, []step{{kind: keyStep, key: "items"}, {kind: indexStep, index: 0}, {kind: keyStep, key: "a.b"}, {kind: wildcardStep}}, path.steps)
+	}
+	{
+		path, err := ParsePath("name")
+		require.NoError(t, err)
+		assert.Equal(t, []step{{kind: keyStep, key: "name"}}, path.steps)
+	}
+	{
+		path, err := ParsePath("$..image")
+		require.NoError(t, err)
+		assert.Equal(t, []step{{kind: descendantStep}, {kind: keyStep, key: "image"}}, path.steps)
+	}
+	{
+		path, err := ParsePath("$.a..[0]")
+		require.NoError(t, err)
+		assert.Equal(t, []step{{kind: keyStep, key: "a"}, {kind: descendantStep}, {kind: indexStep, index: 0}}, path.steps)
+	}
+	{
+		path, err := ParsePath("$")
+		require.NoError(t, err)
+		assert.Equal(t, []step{}, path.steps)
+	}
+}
+
+func TestParsePath_Invalid(t *testing.T) {
+
+	{
+		_, err := ParsePath("$.a...b")
+		assert.EqualError(t, err, `invalid path "$.a...b"`)
+	}
+	{
+		_, err := ParsePath("$.a[0")
+		assert.EqualError(t, err, `invalid path "$.a[0"`)
+	}
+	{
+		_, err := ParsePath("$.a[x]")
+		assert.EqualError(t, err, `invalid path "$.a[x]"`)
+	}
+	{
+		_, err := ParsePath("$a")
+		assert.EqualError(t, err, `invalid path "$a"`)
+	}
+}
+
+func TestPath_Match(t *testing.T) {
+
+	path, err := ParsePath("$.a[*].b")
+	require.NoError(t, err)
+
+	assert.True(t, path.match([]step{{kind: keyStep, key: "a"}, {kind: indexStep, index: 3}, {kind: keyStep, key: "b"}}))
+	assert.False(t, path.match([]step{{kind: keyStep, key: "a"}, {kind: indexStep, index: 3}, {kind: keyStep, key: "c"}}))
+	assert.False(t, path.match([]step{{kind: keyStep, key: "a"}, {kind: indexStep, index: 3}}))
+	assert.False(t, path.match([]step{{kind: indexStep, index: 0}, {kind: indexStep, index: 3}, {kind: keyStep, key: "b"}}))
+}
+
+func TestPath_Match_Descendant(t *testing.T) {
+
+	path, err := ParsePath("$..image")
+	require.NoError(t, err)
+
+	assert.True(t, path.match([]step{{kind: keyStep, key: "image"}}))
+	assert.True(t, path.match([]step{{kind: keyStep, key: "a"}, {kind: indexStep, index: 3}, {kind: keyStep, key: "image"}}))
+	assert.False(t, path.match([]step{{kind: keyStep, key: "image"}, {kind: keyStep, key: "a"}}))
+	assert.False(t, path.match([]step{}))
+
+	all, err := ParsePath("$..*")
+	require.NoError(t, err)
+
+	assert.True(t, all.match([]step{{kind: keyStep, key: "a"}}))
+	assert.True(t, all.match([]step{{kind: keyStep, key: "a"}, {kind: indexStep, index: 3}}))
+	assert.False(t, all.match([]step{}))
+}
@@ -0,0 +1,245 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/onozaty/rcf/replace"
+)
+
+type propertiesFormat struct {
+	keys []string
+}
+
+// Javaのpropertiesファイル
+// keysが指定された場合は、そのキーの値のみが対象
+func NewProperties(keys []string) (Format, error) {
+
+	return &propertiesFormat{
+		keys: keys,
+	}, nil
+}
+
+func (f *propertiesFormat) Replace(s string, replacer replace.Replacer) (string, error) {
+
+	edits := []edit{}
+	pos := 0
+	for pos < len(s) {
+
+		start := skipPropertiesSpaces(s, pos)
+		var end int
+		if start < len(s) && (s[start] == '#' || s[start] == '!') {
+			// コメントは継続行にならない
+			end = strings.IndexAny(s[start:], "\r\n")
+			if end == -1 {
+				end = len(s)
+			} else {
+				end += start
+			}
+		} else {
+			end = propertiesLineEnd(s, start)
+
+			if start < end {
+				key, valueStart, err := parsePropertiesKey(s[:end], start)
+				if err != nil {
+					return "", fmt.Errorf("line %d: %w", strings.Count(s[:start], "\n")+1, err)
+				}
+
+				if len(f.keys) == 0 || contains(f.keys, key) {
+					raw := s[valueStart:end]
+					value, err := unescapeProperties(raw)
+					if err != nil {
+						return "", fmt.Errorf("line %d: %w", strings.Count(s[:start], "\n")+1, err)
+					}
+
+					replaced := replacer.Replace(value)
+					if replaced != value {
+						edits = append(edits, edit{start: valueStart, end: end, text: escapeProperties(replaced, needsUnicodeEscape(raw))})
+					}
+				}
+			}
+		}
+
+		pos = end
+		if strings.HasPrefix(s[pos:], "\r\n") {
+			pos += 2
+		} else if pos < len(s) {
+			pos++
+		}
+	}
+
+	return applyEdits(s, edits), nil
+}
+
+func isPropertiesSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\f'
+}
+
+func skipPropertiesSpaces(s string, pos int) int {
+
+	for pos < len(s) && isPropertiesSpace(s[pos]) {
+		pos++
+	}
+
+	return pos
+}
+
+// 行末の \ による継続行を含めた、論理的な行の終わり
+func propertiesLineEnd(s string, start int) int {
+
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+			if strings.HasPrefix(s[i:], "\r\n") {
+				i++
+			}
+		case '\r', '\n':
+			return i
+		}
+	}
+
+	return len(s)
+}
+
+func parsePropertiesKey(s string, start int) (string, int, error) {
+
+	pos := start
+	for pos < len(s) && s[pos] != '=' && s[pos] != ':' && !isPropertiesSpace(s[pos]) {
+		if s[pos] == '\\' {
+			pos++
+		}
+		pos++
+	}
+	if pos > len(s) {
+		pos = len(s)
+	}
+
+	key, err := unescapeProperties(s[start:pos])
+	if err != nil {
+		return "", 0, err
+	}
+
+	// 区切りは = か : (前後の空白は無視)、もしくは空白のみ
+	pos = skipPropertiesSpaces(s, pos)
+	if pos < len(s) && (s[pos] == '=' || s[pos] == ':') {
+		pos = skipPropertiesSpaces(s, pos+1)
+	}
+
+	return key, pos, nil
+}
+
+func unescapeProperties(s string) (string, error) {
+
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			builder.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		if i >= len(s) {
+			break
+		}
+
+		switch s[i] {
+		case 't':
+			builder.WriteByte('\t')
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'f':
+			builder.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("malformed \\uxxxx encoding")
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\uxxxx encoding")
+			}
+			i += 4
+
+			r := rune(code)
+			if utf16.IsSurrogate(r) && i+7 <= len(s) && strings.HasPrefix(s[i+1:], `\u`) {
+				// サロゲートペア
+				if low, err := strconv.ParseUint(s[i+3:i+7], 16, 16); err == nil {
+					if decoded := utf16.DecodeRune(r, rune(low)); decoded != unicode.ReplacementChar {
+						r = decoded
+						i += 6
+					}
+				}
+			}
+			builder.WriteRune(r)
+		case '\r', '\n':
+			// 継続行 (次の行の先頭の空白は無視)
+			if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+			i = skipPropertiesSpaces(s, i+1) - 1
+		default:
+			builder.WriteByte(s[i])
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// 元の値で\uによるエスケープが使われているか、ASCII以外の文字が無い場合は、0x80から0xFFの文字もエスケープ
+// (0xFFを超える文字は、ISO-8859-1のファイルでも書き込めるように常にエスケープ)
+func needsUnicodeEscape(raw string) bool {
+
+	if strings.Contains(raw, `\u`) {
+		return true
+	}
+
+	for i := 0; i < len(raw); i++ {
+		if raw[i] >= 0x80 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func escapeProperties(value string, unicodeEscape bool) string {
+
+	var builder strings.Builder
+	for i, r := range value {
+		switch {
+		case r == '\\':
+			builder.WriteString(`\\`)
+		case r == '\t':
+			builder.WriteString(`\t`)
+		case r == '\n':
+			builder.WriteString(`\n`)
+		case r == '\r':
+			builder.WriteString(`\r`)
+		case r == '\f':
+			builder.WriteString(`\f`)
+		case r == ' ' && i == 0:
+			// 先頭の空白は区切りとして読み飛ばされてしまうので
+			builder.WriteString(`\ `)
+		case r < 0x20 || r == 0x7f || (r >= 0x80 && unicodeEscape) || r > 0xFF:
+			if r > 0xFFFF {
+				high, low := utf16.EncodeRune(r)
+				fmt.Fprintf(&builder, `\u%04X\u%04X`, high, low)
+			} else {
+				fmt.Fprintf(&builder, `\u%04X`, r)
+			}
+		default:
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
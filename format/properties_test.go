@@ -0,0 +1,119 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/onozaty/rcf/replace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProperties_Key(t *testing.T) {
+
+	format, err := NewProperties([]string{"url", "other.url"})
+	require.NoError(t, err)
+
+	input := "# url=http://a\r\nurl=http://a\r\nother.url : http://b\r\nname http://c\r\n! comment\r\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("http:", "https:"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "# url=http://a\r\nurl=https://a\r\nother.url : https://b\r\nname http://c\r\n! comment\r\n", result)
+}
+
+func TestProperties_UnicodeEscape(t *testing.T) {
+
+	format, err := NewProperties([]string{})
+	require.NoError(t, err)
+
+	// 元の値がエスケープされている場合や、ASCIIのみの場合はエスケープして書き込む
+	input := "message=\\u3053\\u3093\\u306B\\u3061\\u306F\ntitle=Hello\nlabel=こんにちは\n"
+	replacer, err := replace.NewRegexpReplacer("こんにちは|Hello", "さようなら")
+	require.NoError(t, err)
+
+	result, err := format.Replace(input, replacer)
+	require.NoError(t, err)
+
+	// 0xFFを超える文字は常にエスケープ
+	assert.Equal(t, "message=\\u3055\\u3088\\u3046\\u306A\\u3089\ntitle=\\u3055\\u3088\\u3046\\u306A\\u3089\nlabel=\\u3055\\u3088\\u3046\\u306A\\u3089\n", result)
+}
+
+func TestProperties_Latin1(t *testing.T) {
+
+	format, err := NewProperties([]string{})
+	require.NoError(t, err)
+
+	// 元の値にLatin-1の文字がそのまま書かれている場合、Latin-1の文字はそのまま書き込む
+	input := "name=caf\u00e9\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("caf", "日本ü"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "name=\\u65E5\\u672C\u00fc\u00e9\n", result)
+}
+
+func TestProperties_EscapedKey(t *testing.T) {
+
+	format, err := NewProperties([]string{"a b:c"})
+	require.NoError(t, err)
+
+	input := "a\\ b\\:c = x\na=x\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("x", " y\\z\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "a\\ b\\:c = \\ y\\\\z\\n\na=x\n", result)
+}
+
+func TestProperties_Continuation(t *testing.T) {
+
+	format, err := NewProperties([]string{"list"})
+	require.NoError(t, err)
+
+	input := "list=a,\\\n    b,\\\r\n    c\nnext=a\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("b", "x"))
+	require.NoError(t, err)
+
+	// 継続行は1行にまとめられる
+	assert.Equal(t, "list=a,x,c\nnext=a\n", result)
+
+	// 変更が無い場合はそのまま
+	result, err = format.Replace(input, replace.NewStringReplacer("z", "x"))
+	require.NoError(t, err)
+
+	assert.Equal(t, input, result)
+}
+
+func TestProperties_EmptyValue(t *testing.T) {
+
+	format, err := NewProperties([]string{"a"})
+	require.NoError(t, err)
+
+	input := "a=\nb=\n"
+	replacer, err := replace.NewRegexpReplacer("^.*$", "value")
+	require.NoError(t, err)
+
+	result, err := format.Replace(input, replacer)
+	require.NoError(t, err)
+
+	assert.Equal(t, "a=value\nb=\n", result)
+}
+
+func TestProperties_SurrogatePair(t *testing.T) {
+
+	format, err := NewProperties([]string{})
+	require.NoError(t, err)
+
+	input := "a=\\uD83D\\uDE00!"
+	result, err := format.Replace(input, replace.NewStringReplacer("😀", "🙂"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "a=\\uD83D\\uDE42!", result)
+}
+
+func TestProperties_InvalidEscape(t *testing.T) {
+
+	format, err := NewProperties([]string{})
+	require.NoError(t, err)
+
+	_, err = format.Replace("a=1\nb=\\u30", replace.NewStringReplacer("a", "b"))
+	require.Error(t, err)
+	assert.Equal(t, "line 2: malformed \\uxxxx encoding", err.Error())
+}
@@ -0,0 +1,210 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+type element struct {
+	name  string
+	attrs map[string]string
+}
+
+type compoundSelector struct {
+	name    string // 空の場合は任意の要素
+	id      string
+	classes []string
+	attrs   []attrSelector
+	child   bool // 直前の要素の子であること (>)
+}
+
+type attrSelector struct {
+	name     string
+	value    string
+	hasValue bool
+}
+
+// CSSセレクタのサブセット
+// 要素名、#id、.class、[attr]、[attr=value] と、子孫(空白)、子(>)の結合子に対応
+type Selector struct {
+	compounds []compoundSelector
+}
+
+func ParseSelector(str string) (*Selector, error) {
+
+	fields := strings.Fields(strings.ReplaceAll(str, ">", " > "))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("invalid selector \"%s\"", str)
+	}
+
+	compounds := []compoundSelector{}
+	child := false
+	for _, field := range fields {
+		if field == ">" {
+			if child || len(compounds) == 0 {
+				return nil, fmt.Errorf("invalid selector \"%s\"", str)
+			}
+			child = true
+			continue
+		}
+
+		compound, err := parseCompoundSelector(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector \"%s\"", str)
+		}
+		compound.child = child
+		child = false
+
+		compounds = append(compounds, compound)
+	}
+
+	if child {
+		return nil, fmt.Errorf("invalid selector \"%s\"", str)
+	}
+
+	return &Selector{
+		compounds: compounds,
+	}, nil
+}
+
+func parseCompoundSelector(s string) (compoundSelector, error) {
+
+	compound := compoundSelector{}
+
+	end := strings.IndexAny(s, "#.[")
+	if end == -1 {
+		end = len(s)
+	}
+	compound.name = s[:end]
+	if compound.name == "*" {
+		compound.name = ""
+	} else if strings.IndexFunc(compound.name, isInvalidNameRune) != -1 {
+		return compound, fmt.Errorf("invalid name")
+	}
+	s = s[end:]
+
+	for len(s) > 0 {
+		switch s[0] {
+		case '#', '.':
+			end := strings.IndexAny(s[1:], "#.[")
+			if end == -1 {
+				end = len(s)
+			} else {
+				end++
+			}
+			value := s[1:end]
+			if value == "" {
+				return compound, fmt.Errorf("empty")
+			}
+			if s[0] == '#' {
+				compound.id = value
+			} else {
+				compound.classes = append(compound.classes, value)
+			}
+			s = s[end:]
+
+		case '[':
+			end := strings.Index(s, "]")
+			if end == -1 {
+				return compound, fmt.Errorf("not closed")
+			}
+			inner := s[1:end]
+			attr := attrSelector{name: strings.ToLower(inner)}
+			if i := strings.Index(inner, "="); i != -1 {
+				attr.name = strings.ToLower(inner[:i])
+				attr.value = strings.Trim(inner[i+1:], `"'`)
+				attr.hasValue = true
+			}
+			if attr.name == "" {
+				return compound, fmt.Errorf("empty")
+			}
+			compound.attrs = append(compound.attrs, attr)
+			s = s[end+1:]
+
+		default:
+			return compound, fmt.Errorf("unexpected")
+		}
+	}
+
+	return compound, nil
+}
+
+// 要素の階層(先頭がルート)の末尾の要素がマッチするか
+func (s *Selector) match(ancestors []element) bool {
+
+	return matchCompounds(s.compounds, ancestors)
+}
+
+func matchCompounds(compounds []compoundSelector, ancestors []element) bool {
+
+	if len(ancestors) == 0 {
+		return false
+	}
+
+	last := compounds[len(compounds)-1]
+	if !last.match(ancestors[len(ancestors)-1]) {
+		return false
+	}
+
+	if len(compounds) == 1 {
+		return true
+	}
+
+	rest := compounds[:len(compounds)-1]
+	parents := ancestors[:len(ancestors)-1]
+
+	if last.child {
+		return matchCompounds(rest, parents)
+	}
+
+	for i := len(parents); i > 0; i-- {
+		if matchCompounds(rest, parents[:i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *compoundSelector) match(e element) bool {
+
+	if c.name != "" && !strings.EqualFold(c.name, e.name) {
+		return false
+	}
+
+	if c.id != "" && e.attrs["id"] != c.id {
+		return false
+	}
+
+	classes := strings.Fields(e.attrs["class"])
+	for _, class := range c.classes {
+		if !contains(classes, class) {
+			return false
+		}
+	}
+
+	for _, attr := range c.attrs {
+		value, ok := e.attrs[attr.name]
+		if !ok || (attr.hasValue && value != attr.value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isInvalidNameRune(r rune) bool {
+
+	return !(r == '-' || r == '_' || r == ':' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') || r >= 0x80)
+}
+
+func contains(values []string, target string) bool {
+
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,66 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelector(t *testing.T) {
+
+	selector, err := ParseSelector("div#main > p.note.warn a[href] [lang=ja]")
+	require.NoError(t, err)
+
+	assert.Equal(t, []compoundSelector{
+		{name: "div", id: "main"},
+		{name: "p", classes: []string{"note", "warn"}, child: true},
+		{name: "a", attrs: []attrSelector{{name: "href"}}},
+		{attrs: []attrSelector{{name: "lang", value: "ja", hasValue: true}}},
+	}, selector.compounds)
+}
+
+func TestParseSelector_Invalid(t *testing.T) {
+
+	for _, str := range []string{"", "> a", "a >", "a > > b", "a.", "a[b", "a[]", "a)"} {
+		_, err := ParseSelector(str)
+		assert.EqualError(t, err, `invalid selector "`+str+`"`)
+	}
+}
+
+func TestSelector_Match(t *testing.T) {
+
+	html := element{name: "html", attrs: map[string]string{}}
+	div := element{name: "DIV", attrs: map[string]string{"id": "main", "class": "a b"}}
+	p := element{name: "p", attrs: map[string]string{"lang": "ja"}}
+
+	{
+		selector, err := ParseSelector("div p")
+		require.NoError(t, err)
+
+		assert.True(t, selector.match([]element{html, div, p}))
+		assert.False(t, selector.match([]element{html, p}))
+		assert.False(t, selector.match([]element{html, div}))
+	}
+	{
+		selector, err := ParseSelector("html > p")
+		require.NoError(t, err)
+
+		assert.True(t, selector.match([]element{html, p}))
+		assert.False(t, selector.match([]element{html, div, p}))
+	}
+	{
+		selector, err := ParseSelector("#main.b")
+		require.NoError(t, err)
+
+		assert.True(t, selector.match([]element{html, div}))
+		assert.False(t, selector.match([]element{html, p}))
+	}
+	{
+		selector, err := ParseSelector("*[lang=ja]")
+		require.NoError(t, err)
+
+		assert.True(t, selector.match([]element{p}))
+		assert.False(t, selector.match([]element{div}))
+	}
+}
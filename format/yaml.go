@@ -0,0 +1,247 @@
+package format
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/onozaty/rcf/replace"
+	"gopkg.in/yaml.v3"
+)
+
+type yamlFormat struct {
+	path *Path
+}
+
+func NewYAML(pathStr string) (Format, error) {
+
+	path, err := ParsePath(pathStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &yamlFormat{
+		path: path,
+	}, nil
+}
+
+func (f *yamlFormat) Replace(s string, replacer replace.Replacer) (string, error) {
+
+	lineOffsets := lineStartOffsets(s)
+	edits := []edit{}
+	var editErr error
+
+	var walk func(node *yaml.Node, location []step)
+	walk = func(node *yaml.Node, location []step) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, location)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i+1], appendStep(location, step{kind: keyStep, key: node.Content[i].Value}))
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				walk(child, appendStep(location, step{kind: indexStep, index: i}))
+			}
+		case yaml.ScalarNode:
+			if !f.path.match(location) {
+				return
+			}
+			replaced := replacer.Replace(node.Value)
+			if replaced == node.Value {
+				return
+			}
+			e, err := yamlScalarEdit(s, lineOffsets, node, replaced)
+			if err != nil {
+				// 置換対象なのに書き換えられない場合は、黙って無視せずにエラーとする
+				if editErr == nil {
+					editErr = fmt.Errorf("line %d: the value cannot be replaced: %w", node.Line, err)
+				}
+				return
+			}
+			edits = append(edits, e)
+		}
+	}
+
+	// 複数のドキュメントを含む場合もあるので、順に読み込む
+	decoder := yaml.NewDecoder(strings.NewReader(s))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		walk(&document, []step{})
+	}
+
+	if editErr != nil {
+		return "", editErr
+	}
+
+	return applyEdits(s, edits), nil
+}
+
+func appendStep(location []step, s step) []step {
+
+	appended := make([]step, len(location), len(location)+1)
+	copy(appended, location)
+	return append(appended, s)
+}
+
+func lineStartOffsets(s string) []int {
+
+	offsets := []int{0}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+
+	return offsets
+}
+
+// 1行に収まるスカラー値のみを対象とし、元の記述位置を置き換える
+// ブロックスカラーや複数行にまたがる値は書き換えられない
+func yamlScalarEdit(s string, lineOffsets []int, node *yaml.Node, replaced string) (edit, error) {
+
+	multiLineErr := fmt.Errorf("multi-line values are not supported")
+
+	if node.Line < 1 || node.Line > len(lineOffsets) {
+		return edit{}, multiLineErr
+	}
+
+	style := node.Style &^ yaml.TaggedStyle
+	if style == yaml.LiteralStyle || style == yaml.FoldedStyle {
+		return edit{}, fmt.Errorf("block scalars are not supported")
+	}
+
+	// Columnは文字数で数えられている
+	start := lineOffsets[node.Line-1]
+	for i := 1; i < node.Column && start < len(s); i++ {
+		_, width := utf8.DecodeRuneInString(s[start:])
+		start += width
+	}
+
+	lineEnd := strings.IndexByte(s[start:], '\n')
+	if lineEnd == -1 {
+		lineEnd = len(s)
+	} else {
+		lineEnd += start
+	}
+	line := s[start:lineEnd]
+
+	// Columnはアンカーやタグの位置なので、値の位置まで読み飛ばす
+	for strings.HasPrefix(line, "&") || strings.HasPrefix(line, "!") {
+		end := strings.IndexAny(line, " \t")
+		if end == -1 {
+			// 値が次の行に書かれている
+			return edit{}, multiLineErr
+		}
+		for end < len(line) && (line[end] == ' ' || line[end] == '\t') {
+			end++
+		}
+		start += end
+		line = line[end:]
+	}
+
+	switch style {
+	case 0:
+		if !strings.HasPrefix(line, node.Value) {
+			return edit{}, multiLineErr
+		}
+		tag := node.ShortTag()
+		if node.Style&yaml.TaggedStyle != 0 {
+			// タグが明示されている場合は、値によって型が変わらない
+			tag = ""
+		}
+		return edit{start: start, end: start + len(node.Value), text: yamlPlainScalar(replaced, tag)}, nil
+
+	case yaml.DoubleQuotedStyle:
+		end := closingDoubleQuote(line)
+		if end == -1 {
+			return edit{}, multiLineErr
+		}
+		encoded, err := encodeJSONString(replaced)
+		if err != nil {
+			return edit{}, err
+		}
+		return edit{start: start, end: start + end + 1, text: encoded}, nil
+
+	case yaml.SingleQuotedStyle:
+		end := closingSingleQuote(line)
+		if end == -1 {
+			return edit{}, multiLineErr
+		}
+		return edit{start: start, end: start + end + 1, text: yamlSingleQuotedScalar(replaced)}, nil
+	}
+
+	return edit{}, multiLineErr
+}
+
+func yamlPlainScalar(value string, tag string) string {
+
+	// プレーンのままで同じ値として読み込めない場合や、文字列が数値などに変わってしまう場合はダブルクォートで囲む
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &node); err == nil &&
+		len(node.Content) == 1 &&
+		node.Content[0].Kind == yaml.ScalarNode &&
+		node.Content[0].Style == 0 &&
+		node.Content[0].Value == value &&
+		(tag != "!!str" || node.Content[0].ShortTag() == "!!str") &&
+		!strings.ContainsAny(value, "\n#") {
+		return value
+	}
+
+	encoded, _ := encodeJSONString(value)
+	return encoded
+}
+
+func yamlSingleQuotedScalar(value string) string {
+
+	if strings.Contains(value, "\n") {
+		encoded, _ := encodeJSONString(value)
+		return encoded
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func closingDoubleQuote(s string) int {
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+
+	return -1
+}
+
+func closingSingleQuote(s string) int {
+
+	for i := 1; i < len(s); i++ {
+		if s[i] != '\'' {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\'' {
+			// '' はエスケープされたクォート
+			i++
+			continue
+		}
+		return i
+	}
+
+	return -1
+}
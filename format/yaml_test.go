@@ -0,0 +1,157 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/onozaty/rcf/replace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYAML(t *testing.T) {
+
+	format, err := NewYAML("$.services.*.image")
+	require.NoError(t, err)
+
+	replacer, err := replace.NewRegexpReplacer(":.*$", ":2.0")
+	require.NoError(t, err)
+
+	input := `# comment
+services:
+  web:
+    image: web:1.0 # web
+    ports: [80]
+  db:
+    image: "db:1.0"
+  cache: {image: 'cache:1.0', x: 1}
+image: top:1.0
+`
+	result, err := format.Replace(input, replacer)
+	require.NoError(t, err)
+
+	assert.Equal(t, `# comment
+services:
+  web:
+    image: web:2.0 # web
+    ports: [80]
+  db:
+    image: "db:2.0"
+  cache: {image: 'cache:2.0', x: 1}
+image: top:1.0
+`, result)
+}
+
+func TestYAML_Sequence(t *testing.T) {
+
+	format, err := NewYAML("$.items[*]")
+	require.NoError(t, err)
+
+	input := "items:\n  - あa\n  - b\n  - 'a''s'\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("a", "x"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "items:\n  - あx\n  - b\n  - 'x''s'\n", result)
+}
+
+func TestYAML_Quote(t *testing.T) {
+
+	format, err := NewYAML("$.*")
+	require.NoError(t, err)
+
+	input := "a: x\nb: 'x'\nc: x\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("x", "#: y\n"))
+	require.NoError(t, err)
+
+	// プレーンのままでは表せない値はクォートされる
+	assert.Equal(t, "a: \"#: y\\n\"\nb: \"#: y\\n\"\nc: \"#: y\\n\"\n", result)
+}
+
+func TestYAML_KeepType(t *testing.T) {
+
+	format, err := NewYAML("$.*")
+	require.NoError(t, err)
+
+	{
+		// 文字列が数値や真偽値として読み込まれないようにクォートする
+		input := "tag: latest\nname: latest\n"
+		result, err := format.Replace(input, replace.NewStringReplacer("latest", "1.20"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "tag: \"1.20\"\nname: \"1.20\"\n", result)
+	}
+	{
+		input := "enabled: off-by-default\n"
+		result, err := format.Replace(input, replace.NewStringReplacer("off-by-default", "true"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "enabled: \"true\"\n", result)
+	}
+	{
+		// 元が数値の場合はそのまま
+		input := "port: 8080\n"
+		result, err := format.Replace(input, replace.NewStringReplacer("8080", "9090"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "port: 9090\n", result)
+	}
+}
+
+func TestYAML_MultiDocument(t *testing.T) {
+
+	format, err := NewYAML("$.a")
+	require.NoError(t, err)
+
+	input := "a: x\n---\na: x\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("x", "y"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "a: y\n---\na: y\n", result)
+}
+
+func TestYAML_BlockScalar(t *testing.T) {
+
+	format, err := NewYAML("$.a")
+	require.NoError(t, err)
+
+	// ブロックスカラーは書き換えられないのでエラー
+	_, err = format.Replace("b: x\na: |\n  x\n", replace.NewStringReplacer("x", "y"))
+	assert.EqualError(t, err, "line 2: the value cannot be replaced: block scalars are not supported")
+
+	// 置換されない場合は問題ない
+	input := "a: |\n  x\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("z", "y"))
+	require.NoError(t, err)
+
+	assert.Equal(t, input, result)
+}
+
+func TestYAML_MultiLinePlainScalar(t *testing.T) {
+
+	format, err := NewYAML("$.a")
+	require.NoError(t, err)
+
+	_, err = format.Replace("a: x\n  z\n", replace.NewStringReplacer("x", "y"))
+	assert.EqualError(t, err, "line 1: the value cannot be replaced: multi-line values are not supported")
+}
+
+func TestYAML_AnchorAndTag(t *testing.T) {
+
+	format, err := NewYAML("$..*")
+	require.NoError(t, err)
+
+	input := "base: &img nginx:1.0\nweb: *img\nversion: !!str 1.0\nname: &n !custom 'nginx:1.0'\n"
+	result, err := format.Replace(input, replace.NewStringReplacer("1.0", "2.0"))
+	require.NoError(t, err)
+
+	// エイリアスはアンカーの値を参照するので、そのまま
+	assert.Equal(t, "base: &img nginx:2.0\nweb: *img\nversion: !!str 2.0\nname: &n !custom 'nginx:2.0'\n", result)
+}
+
+func TestYAML_Invalid(t *testing.T) {
+
+	format, err := NewYAML("$.a")
+	require.NoError(t, err)
+
+	_, err = format.Replace("a: [", replace.NewStringReplacer("x", "y"))
+	assert.Error(t, err)
+}
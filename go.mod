@@ -7,10 +7,12 @@ require (
 	github.com/stretchr/testify v1.7.1
 )
 
-require golang.org/x/text v0.3.7
+require (
+	golang.org/x/text v0.3.7
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
@@ -0,0 +1,623 @@
+package gorename
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Goのパッケージレベルの識別子の名前を、その識別子を参照している箇所のみ変更
+type Renamer struct {
+	pkg     string // パッケージ名もしくはインポートパス (空の場合は識別子を宣言しているパッケージ)
+	oldName string
+	newName string
+}
+
+// targetは Old もしくは pkg.Old の形式、replacementは New もしくは pkg.New の形式
+func NewRenamer(target string, replacement string) (*Renamer, error) {
+
+	pkg, oldName := splitQualified(target)
+	replacementPkg, newName := splitQualified(replacement)
+
+	if !token.IsIdentifier(oldName) {
+		return nil, fmt.Errorf("invalid identifier \"%s\"", target)
+	}
+	if !token.IsIdentifier(newName) || (replacementPkg != "" && replacementPkg != pkg) {
+		return nil, fmt.Errorf("invalid identifier \"%s\"", replacement)
+	}
+
+	return &Renamer{
+		pkg:     pkg,
+		oldName: oldName,
+		newName: newName,
+	}, nil
+}
+
+func splitQualified(s string) (string, string) {
+
+	i := strings.LastIndex(s, ".")
+	if i == -1 {
+		return "", s
+	}
+
+	return s[:i], s[i+1:]
+}
+
+// 型チェックの単位
+// テストファイルを含む場合は、インポートされる側とは別に型チェックする
+type unit struct {
+	path  string
+	name  string
+	files []*ast.File
+}
+
+type checked struct {
+	pkg  *types.Package
+	info *types.Info
+}
+
+type loader struct {
+	fset     *token.FileSet
+	sources  map[string][]byte
+	bases    map[string]*unit // インポートパスごとのパッケージ
+	others   []*unit          // テストファイルを含むもの
+	packages map[string]*checked
+	checking map[string]bool
+}
+
+// ビルド制約で対象となるファイルが変わるので、プラットフォームごとに型チェックする
+var platforms = []string{
+	"aix/ppc64", "android/386", "android/amd64", "android/arm", "android/arm64",
+	"darwin/amd64", "darwin/arm64", "dragonfly/amd64",
+	"freebsd/386", "freebsd/amd64", "freebsd/arm", "freebsd/arm64",
+	"illumos/amd64", "ios/amd64", "ios/arm64", "js/wasm",
+	"linux/386", "linux/amd64", "linux/arm", "linux/arm64", "linux/mips", "linux/mips64", "linux/mips64le",
+	"linux/mipsle", "linux/ppc64", "linux/ppc64le", "linux/riscv64", "linux/s390x",
+	"netbsd/386", "netbsd/amd64", "netbsd/arm", "netbsd/arm64",
+	"openbsd/386", "openbsd/amd64", "openbsd/arm", "openbsd/arm64", "openbsd/mips64",
+	"plan9/386", "plan9/amd64", "plan9/arm", "solaris/amd64",
+	"windows/386", "windows/amd64", "windows/arm", "windows/arm64",
+}
+
+// rootのディレクトリ配下のGoのファイルを解析し、変更があったファイルの内容をパスごとに返す
+func (r *Renamer) Rename(root string, recursive bool) (map[string][]byte, error) {
+
+	dirs, err := listSourceDirs(root, recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := map[string][]byte{}
+	positions := map[string]map[int]bool{}
+	built := map[string]bool{}
+	checkedSets := map[string]bool{}
+	found := false
+
+	for _, platform := range platforms {
+		ctxt := build.Default
+		ctxt.GOOS, ctxt.GOARCH = splitPlatform(platform)
+		ctxt.CgoEnabled = true
+
+		matched, err := matchFiles(&ctxt, dirs)
+		if err != nil {
+			return nil, err
+		}
+
+		// 対象となるファイルが同じプラットフォームは、型チェックの結果も同じ
+		key := strings.Join(sortedFiles(matched), "\n")
+		if checkedSets[key] {
+			continue
+		}
+		checkedSets[key] = true
+
+		l := &loader{
+			fset:     token.NewFileSet(),
+			sources:  sources,
+			bases:    map[string]*unit{},
+			packages: map[string]*checked{},
+			checking: map[string]bool{},
+		}
+		if err := l.load(dirs, matched); err != nil {
+			return nil, err
+		}
+		for filePath := range matched {
+			built[filePath] = true
+		}
+
+		platformFound, err := r.collect(l, positions)
+		if err != nil {
+			return nil, err
+		}
+		found = found || platformFound
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%s is not found", r.qualifiedName())
+	}
+
+	// どのプラットフォームでもビルドされないファイル (独自のタグなど) は、参照を判定できない
+	skipped := []string{}
+	for _, dir := range dirs {
+		for _, filePath := range dir.files {
+			if built[filePath] {
+				continue
+			}
+			refers, err := r.mayRefer(filePath)
+			if err != nil {
+				return nil, err
+			}
+			if refers {
+				skipped = append(skipped, filePath)
+			}
+		}
+	}
+	if len(skipped) != 0 {
+		return nil, fmt.Errorf("%s may be referenced from files excluded by build constraints: %s", r.oldName, strings.Join(skipped, ", "))
+	}
+
+	return rewrite(sources, positions, r.oldName, r.newName)
+}
+
+// 1つのプラットフォームで、対象を参照している箇所を集める (対象が宣言されていない場合はfalse)
+func (r *Renamer) collect(l *loader, positions map[string]map[int]bool) (bool, error) {
+
+	target, err := r.findTarget(l)
+	if err != nil || target == nil {
+		return false, err
+	}
+	targetPath := target.Pkg().Path()
+
+	if l.packages[targetPath].pkg.Scope().Lookup(r.newName) != nil {
+		return false, fmt.Errorf("%s is already declared in package %s", r.newName, target.Pkg().Name())
+	}
+
+	// 全てのパッケージを型チェックした上で、対象を参照している識別子を集める
+	units := []*checked{}
+	for _, path := range sortedKeys(l.bases) {
+		c, err := l.check(path)
+		if err != nil {
+			return false, err
+		}
+		units = append(units, c)
+	}
+	for _, u := range l.others {
+		units = append(units, l.checkUnit(u))
+	}
+
+	for _, c := range units {
+		obj := target
+		if c.pkg.Path() == targetPath {
+			// テストファイルを含めて型チェックしたものは、別のオブジェクトになっている
+			obj = c.pkg.Scope().Lookup(r.oldName)
+		}
+
+		idents, err := r.references(l.fset, c, obj)
+		if err != nil {
+			return false, err
+		}
+
+		for _, ident := range idents {
+			if c.pkg.Path() != targetPath && !token.IsExported(r.newName) {
+				return false, fmt.Errorf("%s is referenced from package %s, so it cannot be renamed to unexported %s", r.oldName, c.pkg.Name(), r.newName)
+			}
+
+			position := l.fset.Position(ident.Pos())
+			if positions[position.Filename] == nil {
+				positions[position.Filename] = map[int]bool{}
+			}
+			positions[position.Filename][position.Offset] = true
+		}
+	}
+
+	return true, nil
+}
+
+func (r *Renamer) qualifiedName() string {
+
+	if r.pkg != "" {
+		return r.pkg + "." + r.oldName
+	}
+	return r.oldName
+}
+
+// 変更前の名前の識別子が含まれているか
+func (r *Renamer) mayRefer(filePath string) (bool, error) {
+
+	file, err := parser.ParseFile(token.NewFileSet(), filePath, nil, 0)
+	if err != nil {
+		return false, err
+	}
+
+	refers := false
+	ast.Inspect(file, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Name == r.oldName {
+			refers = true
+		}
+		return !refers
+	})
+
+	return refers, nil
+}
+
+func (r *Renamer) findTarget(l *loader) (types.Object, error) {
+
+	found := []types.Object{}
+	for _, path := range sortedKeys(l.bases) {
+		u := l.bases[path]
+		if r.pkg != "" && r.pkg != u.name && r.pkg != u.path {
+			continue
+		}
+
+		c, err := l.check(path)
+		if err != nil {
+			return nil, err
+		}
+		if obj := c.pkg.Scope().Lookup(r.oldName); obj != nil {
+			found = append(found, obj)
+		}
+	}
+
+	name := r.qualifiedName()
+
+	if len(found) == 0 {
+		return nil, nil
+	}
+	if len(found) > 1 {
+		paths := []string{}
+		for _, obj := range found {
+			paths = append(paths, obj.Pkg().Path())
+		}
+		return nil, fmt.Errorf("%s is ambiguous (%s)", name, strings.Join(paths, ", "))
+	}
+
+	return found[0], nil
+}
+
+// 対象のオブジェクトを参照、宣言している識別子
+// 埋め込みフィールドとして使われている場合は、そのフィールドの参照も含む
+func (r *Renamer) references(fset *token.FileSet, c *checked, obj types.Object) ([]*ast.Ident, error) {
+
+	fields := map[types.Object]bool{}
+	for _, def := range c.info.Defs {
+		if v, ok := def.(*types.Var); ok && v.Embedded() && typeName(v.Type()) == obj {
+			fields[v] = true
+		}
+	}
+
+	idents := []*ast.Ident{}
+	for ident, def := range c.info.Defs {
+		if def == obj {
+			idents = append(idents, ident)
+		}
+	}
+	for ident, use := range c.info.Uses {
+		if use != obj && !fields[use] {
+			continue
+		}
+
+		if c.pkg == obj.Pkg() {
+			// パッケージ内の参照が、変更後の名前の別の宣言で隠れてしまわないか
+			if scope := c.pkg.Scope().Innermost(ident.Pos()); scope != nil {
+				if _, shadow := scope.LookupParent(r.newName, ident.Pos()); shadow != nil && shadow.Parent() != c.pkg.Scope() {
+					return nil, fmt.Errorf("%s: renaming to %s conflicts with the declaration at %s", fset.Position(ident.Pos()), r.newName, fset.Position(shadow.Pos()))
+				}
+			}
+		}
+
+		idents = append(idents, ident)
+	}
+
+	return idents, nil
+}
+
+func typeName(t types.Type) types.Object {
+
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if n, ok := t.(*types.Named); ok {
+		return n.Obj()
+	}
+
+	return nil
+}
+
+// Goのファイルがあるディレクトリ
+type sourceDir struct {
+	path       string
+	importPath string
+	files      []string
+}
+
+func listSourceDirs(root string, recursive bool) ([]*sourceDir, error) {
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	modulePath, moduleDir := findModule(absRoot)
+
+	dirs := []*sourceDir{}
+	err = filepath.WalkDir(absRoot, func(dir string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if dir != absRoot {
+			name := entry.Name()
+			if !recursive || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor" {
+				return filepath.SkipDir
+			}
+		}
+
+		importPath := "_" + filepath.ToSlash(dir)
+		if rel, err := filepath.Rel(moduleDir, dir); moduleDir != "" && err == nil && !strings.HasPrefix(rel, "..") {
+			importPath = path.Join(modulePath, filepath.ToSlash(rel))
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		d := &sourceDir{path: dir, importPath: importPath}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+				d.files = append(d.files, filepath.Join(dir, entry.Name()))
+			}
+		}
+		if len(d.files) != 0 {
+			dirs = append(dirs, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dirs, nil
+}
+
+// ビルド対象となるファイル
+func matchFiles(ctxt *build.Context, dirs []*sourceDir) (map[string]bool, error) {
+
+	matched := map[string]bool{}
+	for _, dir := range dirs {
+		for _, filePath := range dir.files {
+			match, err := ctxt.MatchFile(dir.path, filepath.Base(filePath))
+			if err != nil {
+				return nil, err
+			}
+			if match {
+				matched[filePath] = true
+			}
+		}
+	}
+
+	return matched, nil
+}
+
+func (l *loader) load(dirs []*sourceDir, matched map[string]bool) error {
+
+	for _, dir := range dirs {
+		if err := l.loadDir(dir, matched); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *loader) loadDir(dir *sourceDir, matched map[string]bool) error {
+
+	importPath := dir.importPath
+
+	var base, withTests, xtest *unit
+	hasTests := false
+	for _, filePath := range dir.files {
+		if !matched[filePath] {
+			// ビルド対象外のファイル
+			continue
+		}
+
+		src, ok := l.sources[filePath]
+		if !ok {
+			var err error
+			if src, err = os.ReadFile(filePath); err != nil {
+				return err
+			}
+			l.sources[filePath] = src
+		}
+		file, err := parser.ParseFile(l.fset, filePath, src, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.Base(filePath)
+		pkgName := file.Name.Name
+		isTest := strings.HasSuffix(name, "_test.go")
+		if isTest && strings.HasSuffix(pkgName, "_test") {
+			if xtest == nil {
+				xtest = &unit{path: importPath + "_test", name: pkgName}
+			}
+			xtest.files = append(xtest.files, file)
+			continue
+		}
+
+		if base == nil {
+			base = &unit{path: importPath, name: pkgName}
+			withTests = &unit{path: importPath, name: pkgName}
+		} else if base.name != pkgName {
+			return fmt.Errorf("%s: found packages %s and %s", dir.path, base.name, pkgName)
+		}
+
+		if !isTest {
+			base.files = append(base.files, file)
+		} else {
+			hasTests = true
+		}
+		withTests.files = append(withTests.files, file)
+	}
+
+	if base != nil {
+		l.bases[importPath] = base
+		if hasTests {
+			l.others = append(l.others, withTests)
+		}
+	}
+	if xtest != nil {
+		l.others = append(l.others, xtest)
+	}
+
+	return nil
+}
+
+// インポート可能なパッケージの型チェック (結果は使い回す)
+func (l *loader) check(path string) (*checked, error) {
+
+	if c, ok := l.packages[path]; ok {
+		return c, nil
+	}
+	if l.checking[path] {
+		return nil, fmt.Errorf("import cycle: %s", path)
+	}
+
+	l.checking[path] = true
+	c := l.checkUnit(l.bases[path])
+	l.checking[path] = false
+
+	l.packages[path] = c
+	return c, nil
+}
+
+func (l *loader) checkUnit(u *unit) *checked {
+
+	info := &types.Info{
+		Defs:   map[*ast.Ident]types.Object{},
+		Uses:   map[*ast.Ident]types.Object{},
+		Scopes: map[ast.Node]*types.Scope{},
+	}
+
+	config := &types.Config{
+		Importer: l,
+		// 入力に含まれないパッケージはインポートできないが、対象の参照を判定するには十分なので無視
+		Error: func(err error) {},
+	}
+
+	pkg, _ := config.Check(u.path, l.fset, u.files, info)
+
+	return &checked{
+		pkg:  pkg,
+		info: info,
+	}
+}
+
+func (l *loader) Import(path string) (*types.Package, error) {
+
+	if _, ok := l.bases[path]; !ok {
+		return nil, fmt.Errorf("package %s is not in the input", path)
+	}
+
+	c, err := l.check(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.pkg, nil
+}
+
+func rewrite(sources map[string][]byte, positions map[string]map[int]bool, oldName string, newName string) (map[string][]byte, error) {
+
+	results := map[string][]byte{}
+	for filePath, fileOffsets := range positions {
+		offsets := []int{}
+		for offset := range fileOffsets {
+			offsets = append(offsets, offset)
+		}
+		sort.Ints(offsets)
+
+		src := sources[filePath]
+		var builder strings.Builder
+		last := 0
+		for _, offset := range offsets {
+			builder.Write(src[last:offset])
+			builder.WriteString(newName)
+			last = offset + len(oldName)
+		}
+		builder.Write(src[last:])
+
+		formatted, err := format.Source([]byte(builder.String()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+		results[filePath] = formatted
+	}
+
+	return results, nil
+}
+
+// go.modを親のディレクトリに向かって探し、モジュールのパスとディレクトリを返す
+func findModule(dir string) (string, string) {
+
+	for {
+		file, err := os.Open(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			defer file.Close()
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if strings.HasPrefix(line, "module ") {
+					return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`), dir
+				}
+			}
+			return "", ""
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+func splitPlatform(platform string) (string, string) {
+
+	i := strings.Index(platform, "/")
+	return platform[:i], platform[i+1:]
+}
+
+func sortedFiles(m map[string]bool) []string {
+
+	files := []string{}
+	for file := range m {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	return files
+}
+
+func sortedKeys(m map[string]*unit) []string {
+
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,269 @@
+package gorename
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+
+	d, err := os.MkdirTemp("", "rcf")
+	require.NoError(t, err)
+
+	for name, contents := range files {
+		path := filepath.Join(d, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+	}
+
+	return d
+}
+
+func TestRename(t *testing.T) {
+
+	// ARRANGE
+	d := writeFiles(t, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.17\n",
+		"config/config.go": `package config
+
+// Old is a default value.
+var Old = "Old"
+
+type Settings struct{ Old string }
+
+func Get() string { return Old }
+`,
+		"config/config_test.go": `package config
+
+import "testing"
+
+func TestOld(t *testing.T) { _ = Old }
+`,
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/app/config"
+)
+
+var Old = 1
+
+func main() {
+	s := config.Settings{Old: "a"}
+	fmt.Println(config.Old, s.Old, Old)
+}
+`,
+	})
+	defer os.RemoveAll(d)
+
+	renamer, err := NewRenamer("config.Old", "config.Default")
+	require.NoError(t, err)
+
+	// ACT
+	results, err := renamer.Rename(d, true)
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	assert.Equal(t, `package config
+
+// Old is a default value.
+var Default = "Old"
+
+type Settings struct{ Old string }
+
+func Get() string { return Default }
+`, string(results[filepath.Join(d, "config", "config.go")]))
+
+	assert.Equal(t, `package config
+
+import "testing"
+
+func TestOld(t *testing.T) { _ = Default }
+`, string(results[filepath.Join(d, "config", "config_test.go")]))
+
+	// 別の宣言のOldやフィールドのOldは変更しない
+	assert.Equal(t, `package main
+
+import (
+	"fmt"
+
+	"example.com/app/config"
+)
+
+var Old = 1
+
+func main() {
+	s := config.Settings{Old: "a"}
+	fmt.Println(config.Default, s.Old, Old)
+}
+`, string(results[filepath.Join(d, "main.go")]))
+}
+
+func TestRename_EmbeddedType(t *testing.T) {
+
+	// ARRANGE
+	d := writeFiles(t, map[string]string{
+		"a.go": "package a\n\ntype Base struct{ ID int }\n\ntype User struct {\n\t*Base\n}\n\nfunc f(u User) int { return u.Base.ID }\n",
+	})
+	defer os.RemoveAll(d)
+
+	renamer, err := NewRenamer("Base", "Entity")
+	require.NoError(t, err)
+
+	// ACT
+	results, err := renamer.Rename(d, false)
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n\ntype Entity struct{ ID int }\n\ntype User struct {\n\t*Entity\n}\n\nfunc f(u User) int { return u.Entity.ID }\n", string(results[filepath.Join(d, "a.go")]))
+}
+
+func TestRename_NotFound(t *testing.T) {
+
+	// ARRANGE
+	d := writeFiles(t, map[string]string{
+		"a.go": "package a\n\nfunc f() { Old := 1; _ = Old }\n",
+	})
+	defer os.RemoveAll(d)
+
+	renamer, err := NewRenamer("a.Old", "New")
+	require.NoError(t, err)
+
+	// ACT
+	_, err = renamer.Rename(d, false)
+
+	// ASSERT
+	require.Error(t, err)
+	assert.Equal(t, "a.Old is not found", err.Error())
+}
+
+func TestRename_AlreadyDeclared(t *testing.T) {
+
+	// ARRANGE
+	d := writeFiles(t, map[string]string{
+		"a.go": "package a\n\nvar Old, New = 1, 2\n",
+	})
+	defer os.RemoveAll(d)
+
+	renamer, err := NewRenamer("Old", "New")
+	require.NoError(t, err)
+
+	// ACT
+	_, err = renamer.Rename(d, false)
+
+	// ASSERT
+	require.Error(t, err)
+	assert.Equal(t, "New is already declared in package a", err.Error())
+}
+
+func TestRename_Shadowed(t *testing.T) {
+
+	// ARRANGE
+	d := writeFiles(t, map[string]string{
+		"a.go": "package a\n\nvar Old = 1\n\nfunc f() int {\n\tNew := 2\n\treturn Old + New\n}\n",
+	})
+	defer os.RemoveAll(d)
+
+	renamer, err := NewRenamer("Old", "New")
+	require.NoError(t, err)
+
+	// ACT
+	_, err = renamer.Rename(d, false)
+
+	// ASSERT
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a.go:7:9: renaming to New conflicts with the declaration at ")
+}
+
+func TestRename_Unexported(t *testing.T) {
+
+	// ARRANGE
+	d := writeFiles(t, map[string]string{
+		"go.mod":  "module example.com/app\n",
+		"a/a.go":  "package a\n\nvar Old = 1\n",
+		"main.go": "package main\n\nimport \"example.com/app/a\"\n\nvar x = a.Old\n",
+	})
+	defer os.RemoveAll(d)
+
+	renamer, err := NewRenamer("a.Old", "old")
+	require.NoError(t, err)
+
+	// ACT
+	_, err = renamer.Rename(d, true)
+
+	// ASSERT
+	require.Error(t, err)
+	assert.Equal(t, "Old is referenced from package main, so it cannot be renamed to unexported old", err.Error())
+}
+
+func TestRename_BuildConstraints(t *testing.T) {
+
+	// ARRANGE
+	d := writeFiles(t, map[string]string{
+		"go.mod":       "module example.com/app\n",
+		"p/p.go":       "package p\n\nfunc Old() {}\n",
+		"w_windows.go": "package main\n\nimport \"example.com/app/p\"\n\nfunc w() { p.Old() }\n",
+		"w_linux.go":   "package main\n\nimport \"example.com/app/p\"\n\nfunc w() { p.Old() }\n",
+		"w_arm64.go":   "//go:build darwin\n\npackage main\n\nimport \"example.com/app/p\"\n\nfunc v() { p.Old() }\n",
+		"main.go":      "package main\n\nfunc main() {}\n",
+	})
+	defer os.RemoveAll(d)
+
+	renamer, err := NewRenamer("p.Old", "New")
+	require.NoError(t, err)
+
+	// ACT
+	results, err := renamer.Rename(d, true)
+
+	// ASSERT
+	require.NoError(t, err)
+
+	// 実行しているプラットフォーム以外のファイルも変更
+	assert.Len(t, results, 4)
+	assert.Equal(t, "package main\n\nimport \"example.com/app/p\"\n\nfunc w() { p.New() }\n", string(results[filepath.Join(d, "w_windows.go")]))
+	assert.Equal(t, "package main\n\nimport \"example.com/app/p\"\n\nfunc w() { p.New() }\n", string(results[filepath.Join(d, "w_linux.go")]))
+	assert.Equal(t, "//go:build darwin\n\npackage main\n\nimport \"example.com/app/p\"\n\nfunc v() { p.New() }\n", string(results[filepath.Join(d, "w_arm64.go")]))
+}
+
+func TestRename_ExcludedFile(t *testing.T) {
+
+	// ARRANGE
+	d := writeFiles(t, map[string]string{
+		"a.go":     "package a\n\nfunc Old() {}\n",
+		"b.go":     "//go:build integration\n\npackage a\n\nfunc b() { Old() }\n",
+		"c.go":     "//go:build ignore\n\npackage a\n\nfunc c() {}\n",
+		"a_old.go": "package a\n\nfunc d() { Old() }\n",
+	})
+	defer os.RemoveAll(d)
+
+	renamer, err := NewRenamer("Old", "New")
+	require.NoError(t, err)
+
+	// ACT
+	_, err = renamer.Rename(d, false)
+
+	// ASSERT
+	require.Error(t, err)
+	assert.Equal(t, "Old may be referenced from files excluded by build constraints: "+filepath.Join(d, "b.go"), err.Error())
+}
+
+func TestNewRenamer_Invalid(t *testing.T) {
+
+	{
+		_, err := NewRenamer("a.1", "b")
+		require.Error(t, err)
+		assert.Equal(t, "invalid identifier \"a.1\"", err.Error())
+	}
+	{
+		_, err := NewRenamer("a.Old", "b.New")
+		require.Error(t, err)
+		assert.Equal(t, "invalid identifier \"b.New\"", err.Error())
+	}
+}
@@ -1,236 +1,494 @@
-package main
-
-import (
-	"fmt"
-	"io"
-	"os"
-	"path/filepath"
-	"strconv"
-
-	"github.com/onozaty/rcf/encoder"
-	r "github.com/onozaty/rcf/replace"
-	"github.com/spf13/pflag"
-)
-
-const (
-	OK = 0
-	NG = 1
-)
-
-var (
-	Version = "dev"
-	Commit  = "none"
-)
-
-func main() {
-	os.Exit(run(os.Args[1:]))
-}
-
-func run(args []string) int {
-
-	var inputPath string
-	var outputPath string
-	var targetStr string
-	var targetRegex string
-	var replacement string
-	var escapeSequence bool
-	var charset string
-	var overwrite bool
-	var recursive bool
-	var help bool
-
-	// テストで繰り返しパースすることになるので
-	flag := pflag.NewFlagSet("rcf", pflag.ContinueOnError)
-	flag.StringVarP(&inputPath, "input", "i", "", "Input file/dir path.")
-	flag.StringVarP(&targetRegex, "regex", "r", "", "Target regex.")
-	flag.StringVarP(&targetStr, "string", "s", "", "Target string.")
-	flag.StringVarP(&replacement, "replacement", "t", "", "Replacement.")
-	flag.BoolVarP(&escapeSequence, "escape", "e", false, "Enable escape sequence.")
-	flag.BoolVarP(&recursive, "recursive", "R", false, "Recursively traverse the input dir.")
-	flag.StringVarP(&charset, "charset", "c", "UTF-8", "Charset.")
-	flag.StringVarP(&outputPath, "output", "o", "", "Output file/dir path.")
-	flag.BoolVarP(&overwrite, "overwrite", "O", false, "Overwrite the input file.")
-	flag.BoolVarP(&help, "help", "h", false, "Help.")
-	flag.SortFlags = false
-	flag.Usage = func() {
-		usage(flag, os.Stderr)
-	}
-
-	if err := flag.Parse(args); err != nil {
-		usage(flag, os.Stderr)
-		fmt.Fprintln(os.Stderr, "\nError:", err)
-		return NG
-	}
-
-	if help {
-		usage(flag, os.Stdout)
-		return OK
-	}
-
-	if inputPath == "" || (outputPath == "" && !overwrite) || (targetRegex == "" && targetStr == "") {
-		usage(flag, os.Stderr)
-		return NG
-	}
-
-	if escapeSequence {
-		// Unquoteした文字列を再設定
-		if unquoted, err := unquote(targetRegex); err != nil {
-			fmt.Fprintln(os.Stderr, "\nError: --regex is invalid string:", targetRegex)
-			return NG
-		} else {
-			targetRegex = unquoted
-		}
-
-		if unquoted, err := unquote(targetStr); err != nil {
-			fmt.Fprintln(os.Stderr, "\nError: --string is invalid string:", targetStr)
-			return NG
-		} else {
-			targetStr = unquoted
-		}
-
-		if unquoted, err := unquote(replacement); err != nil {
-			fmt.Fprintln(os.Stderr, "\nError: --replacement is invalid string:", replacement)
-			return NG
-		} else {
-			replacement = unquoted
-		}
-	}
-
-	if outputPath == "" && overwrite {
-		// 上書き指定されていた場合、入力と同じものを指定
-		outputPath = inputPath
-	}
-
-	condition := condition{
-		targetRegex: targetRegex,
-		targetStr:   targetStr,
-		replacement: replacement,
-	}
-
-	if err := replace(inputPath, outputPath, condition, charset, recursive); err != nil {
-		fmt.Fprintln(os.Stderr, "\nError:", err)
-		return NG
-	}
-
-	return OK
-}
-
-func usage(flag *pflag.FlagSet, w io.Writer) {
-
-	fmt.Fprintf(w, "rcf v%s (%s)\n\n", Version, Commit)
-	fmt.Fprintf(w, "Usage: rcf -i INPUT [-r REGEX | -s STRING] -t REPLACEMENT [--escape] [--recursive] [-c CHARSET] [-o OUTPUT | --overwrite]\n\nFlags\n")
-	flag.SetOutput(w)
-	flag.PrintDefaults()
-}
-
-type condition struct {
-	targetRegex string
-	targetStr   string
-	replacement string
-}
-
-func replace(inputPath string, outputPath string, condition condition, charset string, recursive bool) error {
-
-	encoder, err := encoder.NewEncoder(charset)
-	if err != nil {
-		return err
-	}
-
-	replacer, err := newReplacer(condition)
-	if err != nil {
-		return err
-	}
-
-	inputInfo, err := os.Stat(inputPath)
-	if err != nil {
-		return err
-	}
-
-	if !inputInfo.IsDir() {
-		// ファイル指定
-		return replaceFile(inputPath, outputPath, replacer, encoder)
-	} else {
-		// ディレクトリ指定
-		return replaceFiles(inputPath, outputPath, replacer, encoder, recursive)
-	}
-}
-
-func replaceFiles(inputDirPath string, outputDirPath string, replacer r.Replacer, encoder encoder.Encoder, recursive bool) error {
-
-	entries, err := os.ReadDir(inputDirPath)
-	if err != nil {
-		return err
-	}
-
-	// 出力先のディレクトリが無かったら作っておく
-	_, err = os.Stat(outputDirPath)
-	if os.IsNotExist(err) {
-		if err := os.Mkdir(outputDirPath, os.ModePerm); err != nil {
-			return err
-		}
-	} else if err != nil {
-		return err
-	}
-
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			err := replaceFile(filepath.Join(inputDirPath, entry.Name()), filepath.Join(outputDirPath, entry.Name()), replacer, encoder)
-			if err != nil {
-				return err
-			}
-		} else if recursive {
-			// ディレクトリかつ再帰的にたどる場合
-			if err := replaceFiles(filepath.Join(inputDirPath, entry.Name()), filepath.Join(outputDirPath, entry.Name()), replacer, encoder, recursive); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
-
-func replaceFile(inputFilePath string, outputFilePath string, replacer r.Replacer, encoder encoder.Encoder) error {
-
-	inputBytes, err := os.ReadFile(inputFilePath)
-	if err != nil {
-		return err
-	}
-
-	inputContents, err := encoder.String(inputBytes)
-	if err != nil {
-		return err
-	}
-
-	outputContents := replacer.Replace(inputContents)
-
-	out, err := os.Create(outputFilePath)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	encodedBytes, err := encoder.Bytes(outputContents)
-	if err != nil {
-		return err
-	}
-
-	_, err = out.Write(encodedBytes)
-	return err
-}
-
-func newReplacer(condition condition) (r.Replacer, error) {
-
-	if condition.targetRegex != "" {
-		replacer, err := r.NewRegexpReplacer(condition.targetRegex, condition.replacement)
-		if err != nil {
-			return nil, err
-		}
-		return replacer, nil
-	}
-
-	return r.NewStringReplacer(condition.targetStr, condition.replacement), nil
-}
-
-func unquote(str string) (string, error) {
-	return strconv.Unquote(`"` + str + `"`)
-}
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/onozaty/rcf/encoder"
+	"github.com/onozaty/rcf/rcf"
+	"github.com/spf13/pflag"
+)
+
+const (
+	OK = 0
+	NG = 1
+)
+
+var (
+	Version = "dev"
+	Commit  = "none"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+
+	// rcf config show [OPTIONS] は、置換せずに有効な設定を表示
+	showConfigOnly := false
+	if len(args) != 0 && args[0] == "config" {
+		if len(args) < 2 || args[1] != "show" {
+			fmt.Fprintln(os.Stderr, "Usage: rcf config show [--preset NAME] [OPTIONS]")
+			return NG
+		}
+		showConfigOnly = true
+		args = args[2:]
+	}
+
+	var inputPath string
+	var outputPath string
+	var targetStr string
+	var targetRegex string
+	var replacement string
+	var replacementFile string
+	var targetFile string
+	var regexFile string
+	var bytePattern string
+	var patchAt string
+	var patchBytes string
+	var patchExpect string
+	var patchFile string
+	var preserveLength bool
+	var pad string
+	var hexDump bool
+	var dumpContext int
+	var operation string
+	var escapeSequence bool
+	var multiline bool
+	var dotAll bool
+	var lineMode bool
+	var lines string
+	var betweenStart string
+	var betweenEnd string
+	var after string
+	var before string
+	var inclusive bool
+	var sourceScope string
+	var goRename bool
+	var renamePaths bool
+	var dryRun bool
+	var pathsOnly bool
+	var mirror bool
+	var archive bool
+	var occurrence int
+	var maxCount int
+	var maxTotal int
+	var ifRegex string
+	var unlessRegex string
+	var checkIdempotent bool
+	var filesWith string
+	var filesWithout string
+	var formatName string
+	var valuePath string
+	var csv bool
+	var columns []string
+	var csvDelimiter string
+	var csvQuote string
+	var noHeader bool
+	var selector string
+	var attrs []string
+	var keys []string
+	var sections []string
+	var charset string
+	var compress string
+	var overwrite bool
+	var recursive bool
+	var preset string
+	var help bool
+
+	// テストで繰り返しパースすることになるので
+	flag := pflag.NewFlagSet("rcf", pflag.ContinueOnError)
+	flag.StringVarP(&inputPath, "input", "i", "", "Input file/dir path.")
+	flag.StringVarP(&targetRegex, "regex", "r", "", "Target regex.")
+	flag.StringVarP(&targetStr, "string", "s", "", "Target string.")
+	flag.StringVarP(&replacement, "replacement", "t", "", "Replacement.")
+	flag.StringVar(&replacementFile, "replacement-file", "", "File containing the replacement.")
+	flag.StringVar(&targetFile, "target-file", "", "File containing the target string.")
+	flag.StringVar(&regexFile, "regex-file", "", "File containing the target regex.")
+	flag.StringVar(&bytePattern, "binary-pattern", "", "Target binary pattern. (e.g. '\"PK\" 03 04 ?? [00-0F]')")
+	flag.StringVar(&patchAt, "at", "", "Offset of the bytes to patch. (e.g. 0x1F0)")
+	flag.StringVar(&patchBytes, "bytes", "", "Bytes to write at the offset of --at. (e.g. 90 90)")
+	flag.StringVar(&patchExpect, "expect", "", "Bytes expected at the offset of --at before patching.")
+	flag.StringVar(&patchFile, "patch-file", "", "File containing patches. (OFFSET EXPECTED BYTES per line)")
+	flag.BoolVar(&preserveLength, "preserve-length", false, "Reject replacements that change the byte length.")
+	flag.StringVar(&pad, "pad", "", "Byte to pad shorter replacements with when --preserve-length. (e.g. 00)")
+	flag.BoolVar(&hexDump, "hex-dump", false, "Show a hex dump of each replacement instead of writing files.")
+	flag.IntVar(&dumpContext, "dump-context", 16, "Number of bytes shown before and after each replacement in --hex-dump.")
+	flag.StringVarP(&operation, "operation", "p", "replace", "Operation. (replace, delete-line, insert-before, insert-after, ensure-present, ensure-absent)")
+	flag.BoolVarP(&escapeSequence, "escape", "e", false, "Enable escape sequence.")
+	flag.BoolVar(&multiline, "multiline", false, "Make ^ and $ match at the beginning and end of each line.")
+	flag.BoolVar(&dotAll, "dotall", false, "Make . match newlines.")
+	flag.BoolVar(&lineMode, "line-mode", false, "Replace line by line.")
+	flag.StringVar(&lines, "lines", "", "Target line range. (e.g. 10:50)")
+	flag.StringVar(&betweenStart, "between-start", "", "Regex of the start marker of target regions.")
+	flag.StringVar(&betweenEnd, "between-end", "", "Regex of the end marker of target regions.")
+	flag.StringVar(&after, "after", "", "Regex of the anchor after which is the target.")
+	flag.StringVar(&before, "before", "", "Regex of the anchor before which is the target.")
+	flag.BoolVar(&inclusive, "inclusive", false, "Include markers and anchors in the target.")
+	flag.StringVar(&sourceScope, "scope", "", "Part of the source code to replace. (comments, strings, code)")
+	flag.BoolVar(&goRename, "go-rename", false, "Rename the Go identifier specified by -s to -t where it is referenced. (e.g. -s pkg.Old -t New)")
+	flag.IntVar(&occurrence, "occurrence", 0, "Replace only the Nth match in each file. (negative counts from the last)")
+	flag.IntVar(&maxCount, "max-count", 0, "Maximum number of replacements per file.")
+	flag.IntVar(&maxTotal, "max-total", 0, "Maximum number of replacements in total.")
+	flag.StringVar(&ifRegex, "if", "", "Replace only if the file contains a match of the regex.")
+	flag.StringVar(&unlessRegex, "unless", "", "Replace only if the file does not contain a match of the regex.")
+	flag.BoolVar(&checkIdempotent, "check-idempotent", false, "Warn if replacing again would change the result.")
+	flag.StringVar(&filesWith, "files-with", "", "Process only files that contain a match of the regex.")
+	flag.StringVar(&filesWithout, "files-without", "", "Process only files that do not contain a match of the regex.")
+	flag.StringVar(&formatName, "format", "", "Format of the file to replace only values. (json, yaml, csv, xml, html, properties, ini, dotenv)")
+	flag.StringVar(&valuePath, "path", "$..*", "Path expression of the target values. (e.g. $.services.*.image)")
+	flag.BoolVar(&csv, "csv", false, "Same as --format csv.")
+	flag.StringArrayVar(&columns, "column", []string{}, "Name or index (1-based) of the target CSV column. (default all columns)")
+	flag.StringVar(&csvDelimiter, "csv-delimiter", ",", "Delimiter of CSV.")
+	flag.StringVar(&csvQuote, "csv-quote", `"`, "Quote character of CSV.")
+	flag.BoolVar(&noHeader, "no-header", false, "CSV has no header line.")
+	flag.StringVar(&selector, "selector", "", "CSS selector of the target XML/HTML elements. (e.g. div.content > p)")
+	flag.StringArrayVar(&attrs, "attr", []string{}, "Name of the target XML/HTML attribute. (default text nodes)")
+	flag.StringArrayVar(&keys, "key", []string{}, "Key of the target properties/INI/dotenv values. (default all keys)")
+	flag.StringArrayVar(&sections, "section", []string{}, "Section of the target INI values. (default all sections)")
+	flag.BoolVar(&renamePaths, "rename-paths", false, "Also replace file and directory names.")
+	flag.BoolVar(&pathsOnly, "paths-only", false, "Replace only file and directory names.")
+	flag.BoolVar(&dryRun, "dry-run", false, "Show the paths to be renamed (or the files to be changed by --go-rename) instead of writing files.")
+	flag.BoolVarP(&recursive, "recursive", "R", false, "Recursively traverse the input dir.")
+	flag.BoolVar(&mirror, "mirror", false, "Copy files that are not replaced, symlinks and directory metadata to the output dir as well.")
+	flag.BoolVar(&archive, "archive", false, "Replace the entries of the input zip/tar/tar.gz file and output an archive of the same format.")
+	flag.StringVarP(&charset, "charset", "c", "UTF-8", "Charset.")
+	flag.StringVar(&compress, "compress", "auto", "Compression of the input files. (auto, none, gzip, bzip2)")
+	flag.StringVarP(&outputPath, "output", "o", "", "Output file/dir path.")
+	flag.BoolVarP(&overwrite, "overwrite", "O", false, "Overwrite the input file.")
+	flag.StringVar(&preset, "preset", "", "Name of the preset in "+configFileName+".")
+	flag.BoolVarP(&help, "help", "h", false, "Help.")
+	flag.SortFlags = false
+	flag.Usage = func() {
+		usage(flag, os.Stderr)
+	}
+
+	if err := flag.Parse(args); err != nil {
+		usage(flag, os.Stderr)
+		fmt.Fprintln(os.Stderr, "\nError:", err)
+		return NG
+	}
+
+	if help {
+		usage(flag, os.Stdout)
+		return OK
+	}
+
+	// 設定ファイルを適用すると区別できなくなるので、コマンドラインで指定されたものを覚えておく
+	cliFlags := map[string]bool{}
+	flag.Visit(func(f *pflag.Flag) {
+		cliFlags[f.Name] = true
+	})
+
+	// 設定ファイルの値は、コマンドラインで指定されていないものにのみ適用
+	configPath := ""
+	if configDir != "" {
+		path, err := findConfig(configDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "\nError:", err)
+			return NG
+		}
+		configPath = path
+	}
+	if configPath != "" {
+		config, err := loadConfig(configPath)
+		if err == nil {
+			err = applyConfig(flag, config, preset)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "\nError:", err)
+			return NG
+		}
+	} else if preset != "" {
+		fmt.Fprintln(os.Stderr, "\nError: --preset requires "+configFileName)
+		return NG
+	}
+
+	if showConfigOnly {
+		if err := showConfig(flag, configPath, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "\nError:", err)
+			return NG
+		}
+		return OK
+	}
+
+	if inputPath == "" || (outputPath == "" && !overwrite && !hexDump && !dryRun) || (targetRegex == "" && targetStr == "" && targetFile == "" && regexFile == "" && bytePattern == "" && patchAt == "" && patchFile == "") {
+		usage(flag, os.Stderr)
+		return NG
+	}
+
+	if goRename {
+		// 識別子の変更に関係しないフラグは、どのフラグが原因か分かるようにここで確認
+		// (設定ファイルの値は、識別子の変更では使わないだけなのでエラーにしない)
+		goRenameFlags := map[string]bool{
+			"input": true, "output": true, "overwrite": true, "string": true, "replacement": true,
+			"target-file": true, "replacement-file": true, "recursive": true, "mirror": true,
+			"go-rename": true, "dry-run": true, "preset": true,
+		}
+		var unsupported string
+		flag.Visit(func(f *pflag.Flag) {
+			if unsupported == "" && cliFlags[f.Name] && !goRenameFlags[f.Name] {
+				unsupported = f.Name
+			}
+		})
+		if unsupported != "" {
+			fmt.Fprintln(os.Stderr, "\nError: --go-rename cannot be used with --"+unsupported)
+			return NG
+		}
+	}
+
+	if csv {
+		formatName = "csv"
+	}
+
+	if formatName == "csv" {
+		// タブなどを指定できるように、区切り文字はエスケープシーケンスとして扱う
+		if unquoted, err := unquote(csvDelimiter); err != nil {
+			fmt.Fprintln(os.Stderr, "\nError: invalid delimiter \""+csvDelimiter+"\"")
+			return NG
+		} else {
+			csvDelimiter = unquoted
+		}
+	}
+
+	if (formatName == "xml" || formatName == "html") && !flag.Changed("charset") {
+		// XMLやHTMLは、文書内で宣言された文字コードに従う
+		charset = "auto"
+	}
+
+	lineStart, lineEnd, err := parseLineRange(lines)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "\nError: --lines is invalid range:", lines)
+		return NG
+	}
+
+	if escapeSequence {
+		// Unquoteした文字列を再設定
+		if unquoted, err := unquote(targetRegex); err != nil {
+			fmt.Fprintln(os.Stderr, "\nError: --regex is invalid string:", targetRegex)
+			return NG
+		} else {
+			targetRegex = unquoted
+		}
+
+		if unquoted, err := unquote(targetStr); err != nil {
+			fmt.Fprintln(os.Stderr, "\nError: --string is invalid string:", targetStr)
+			return NG
+		} else {
+			targetStr = unquoted
+		}
+
+		if unquoted, err := unquote(replacement); err != nil {
+			fmt.Fprintln(os.Stderr, "\nError: --replacement is invalid string:", replacement)
+			return NG
+		} else {
+			replacement = unquoted
+		}
+	}
+
+	// ファイルで指定されたものは、エスケープシーケンスとして扱わずにそのまま使う
+	if regexFile != "" {
+		if contents, err := readTextFile(regexFile, charset); err != nil {
+			fmt.Fprintln(os.Stderr, "\nError:", err)
+			return NG
+		} else {
+			targetRegex = contents
+		}
+	}
+
+	if targetFile != "" {
+		if contents, err := readTextFile(targetFile, charset); err != nil {
+			fmt.Fprintln(os.Stderr, "\nError:", err)
+			return NG
+		} else {
+			targetStr = contents
+		}
+	}
+
+	if replacementFile != "" {
+		if contents, err := readTextFile(replacementFile, charset); err != nil {
+			fmt.Fprintln(os.Stderr, "\nError:", err)
+			return NG
+		} else {
+			replacement = contents
+		}
+	}
+
+	if expanded, err := expandEnv(replacement); err != nil {
+		fmt.Fprintln(os.Stderr, "\nError:", err)
+		return NG
+	} else {
+		replacement = expanded
+	}
+
+	if outputPath == "" && (overwrite || hexDump || dryRun) {
+		// 上書き指定されていた場合、入力と同じものを指定 (16進ダンプや名前の表示のみの場合は書き込まない)
+		outputPath = inputPath
+	}
+
+	if formatName == "properties" && !flag.Changed("charset") {
+		// propertiesファイルはISO-8859-1 (--target-file などは指定された文字コードで読み込む)
+		charset = "latin1"
+	}
+
+	options := rcf.Options{
+		TargetRegex:     targetRegex,
+		TargetString:    targetStr,
+		BinaryPattern:   bytePattern,
+		Replacement:     replacement,
+		Operation:       operation,
+		Multiline:       multiline,
+		DotAll:          dotAll,
+		PatchAt:         patchAt,
+		PatchBytes:      patchBytes,
+		PatchExpect:     patchExpect,
+		PatchFile:       patchFile,
+		PreserveLength:  preserveLength,
+		Pad:             pad,
+		HexDump:         hexDump,
+		DumpContext:     dumpContext,
+		LineMode:        lineMode,
+		LineStart:       lineStart,
+		LineEnd:         lineEnd,
+		BetweenStart:    betweenStart,
+		BetweenEnd:      betweenEnd,
+		After:           after,
+		Before:          before,
+		Inclusive:       inclusive,
+		SourceScope:     sourceScope,
+		Occurrence:      occurrence,
+		MaxCount:        maxCount,
+		MaxTotal:        maxTotal,
+		If:              ifRegex,
+		Unless:          unlessRegex,
+		FilesWith:       filesWith,
+		FilesWithout:    filesWithout,
+		Format:          formatName,
+		Path:            valuePath,
+		Columns:         columns,
+		CSVDelimiter:    csvDelimiter,
+		CSVQuote:        csvQuote,
+		CSVNoHeader:     noHeader,
+		Selector:        selector,
+		Attrs:           attrs,
+		Keys:            keys,
+		Sections:        sections,
+		RenamePaths:     renamePaths,
+		PathsOnly:       pathsOnly,
+		DryRun:          dryRun,
+		Mirror:          mirror,
+		Archive:         archive,
+		GoRename:        goRename,
+		Charset:         charset,
+		Compress:        compress,
+		Recursive:       recursive,
+		CheckIdempotent: checkIdempotent,
+	}
+
+	if goRename {
+		// 設定ファイルの値のうち、識別子の変更に関係しないものは使わない
+		options = rcf.Options{
+			TargetString: targetStr,
+			Replacement:  replacement,
+			GoRename:     goRename,
+			Mirror:       mirror,
+			DryRun:       dryRun,
+			Recursive:    recursive,
+		}
+	}
+
+	if err := rcf.Run(context.Background(), inputPath, outputPath, options); err != nil {
+		fmt.Fprintln(os.Stderr, "\nError:", err)
+		return NG
+	}
+
+	return OK
+}
+
+func usage(flag *pflag.FlagSet, w io.Writer) {
+
+	fmt.Fprintf(w, "rcf v%s (%s)\n\n", Version, Commit)
+	fmt.Fprintf(w, "Usage: rcf -i INPUT [-r REGEX | -s STRING] -t REPLACEMENT [OPTIONS] [-o OUTPUT | --overwrite]\n")
+	fmt.Fprintf(w, "       rcf config show [--preset NAME] [OPTIONS]\n\nFlags\n")
+	flag.SetOutput(w)
+	flag.PrintDefaults()
+}
+
+func parseLineRange(str string) (int, int, error) {
+
+	if str == "" {
+		return 0, 0, nil
+	}
+
+	// "10:50"、"10:"、":50"、"10" の形式
+	startStr, endStr := str, str
+	if i := strings.Index(str, ":"); i != -1 {
+		startStr, endStr = str[:i], str[i+1:]
+	}
+
+	start := 1
+	if startStr != "" {
+		n, err := strconv.Atoi(startStr)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("invalid line range \"%s\"", str)
+		}
+		start = n
+	}
+
+	end := 0
+	if endStr != "" {
+		n, err := strconv.Atoi(endStr)
+		if err != nil || n < start {
+			return 0, 0, fmt.Errorf("invalid line range \"%s\"", str)
+		}
+		end = n
+	}
+
+	return start, end, nil
+}
+
+func readTextFile(path string, charset string) (string, error) {
+
+	encoder, err := encoder.NewEncoder(charset)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return encoder.String(b)
+}
+
+var envPattern = regexp.MustCompile(`\$\{env:([^}]*)\}`)
+
+func expandEnv(str string) (string, error) {
+
+	var err error
+	expanded := envPattern.ReplaceAllStringFunc(str, func(match string) string {
+		name := envPattern.FindStringSubmatch(match)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("environment variable \"%s\" is not set", name)
+		}
+		return value
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return expanded, nil
+}
+
+func unquote(str string) (string, error) {
+	return strconv.Unquote(`"` + str + `"`)
+}
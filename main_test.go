@@ -1,921 +1,3771 @@
-package main
-
-import (
-	"bytes"
-	"io"
-	"os"
-	"path/filepath"
-	"testing"
-
-	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
-	"golang.org/x/text/encoding"
-	"golang.org/x/text/encoding/japanese"
-)
-
-func TestRun_File_Regex(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteString(t, d, "input.txt", "abc\nabc\naa")
-	output := filepath.Join(d, "output.txt")
-
-	args := []string{
-		"-i", input,
-		"-r", "a$",
-		"-t", "x",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-	replaced := readString(t, output)
-	assert.Equal(t, "abc\nabc\nax", replaced)
-}
-
-func TestRun_File_String(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteString(t, d, "input.txt", "aa.ab.ac.ad.a.b.c.d")
-	output := filepath.Join(d, "output.txt")
-
-	args := []string{
-		"-i", input,
-		"-s", "a.",
-		"-t", "xx",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-	replaced := readString(t, output)
-	assert.Equal(t, "axxab.ac.ad.xxb.c.d", replaced)
-}
-
-func TestRun_Dir_Regex(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createDir(t, d, "input")
-
-	createFileWriteString(t, input, "input1.txt", "abc\nabc\naa")
-	createFileWriteString(t, input, "input2.txt", "a")
-	createFileWriteString(t, input, "input3.txt", "ax")
-
-	output := createDir(t, d, "output")
-
-	args := []string{
-		"-i", input,
-		"-r", "a$",
-		"-t", "x",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-	{
-		replaced := readString(t, filepath.Join(output, "input1.txt"))
-		assert.Equal(t, "abc\nabc\nax", replaced)
-	}
-	{
-		replaced := readString(t, filepath.Join(output, "input2.txt"))
-		assert.Equal(t, "x", replaced)
-	}
-	{
-		replaced := readString(t, filepath.Join(output, "input3.txt"))
-		assert.Equal(t, "ax", replaced)
-	}
-}
-
-func TestRun_File_Regex_Japanese(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteString(t, d, "input.txt", "あいうえおかきくけこ")
-	output := filepath.Join(d, "output.txt")
-
-	args := []string{
-		"-i", input,
-		"-r", "あ.{4}",
-		"-t", "",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-	replaced := readString(t, output)
-	assert.Equal(t, "かきくけこ", replaced)
-}
-
-func TestRun_Dir_String(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createDir(t, d, "input")
-
-	createFileWriteString(t, input, "input1.txt", "abc\na.c\naa")
-	createFileWriteString(t, input, "input2.txt", "")
-	createFileWriteString(t, input, "input3.txt", "a.c")
-
-	output := createDir(t, d, "output")
-
-	args := []string{
-		"-i", input,
-		"-s", "a.c",
-		"-t", "",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-	{
-		replaced := readString(t, filepath.Join(output, "input1.txt"))
-		assert.Equal(t, "abc\n\naa", replaced)
-	}
-	{
-		replaced := readString(t, filepath.Join(output, "input2.txt"))
-		assert.Equal(t, "", replaced)
-	}
-	{
-		replaced := readString(t, filepath.Join(output, "input3.txt"))
-		assert.Equal(t, "", replaced)
-	}
-}
-
-func TestRun_Dir_CreateOutputDir(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createDir(t, d, "input")
-
-	createFileWriteString(t, input, "input1.txt", "abc\nabc\nabc")
-
-	output := filepath.Join(d, "output") // 出力ディレクトリは存在しない状態
-
-	args := []string{
-		"-i", input,
-		"-r", "(?m)c$",
-		"-t", "x",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-	replaced := readString(t, filepath.Join(output, "input1.txt"))
-	assert.Equal(t, "abx\nabx\nabx", replaced)
-}
-
-func TestRun_Dir_Recursive(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createDir(t, d, "input")
-	createFileWriteString(t, input, "1.txt", "abc")
-	createFileWriteString(t, input, "2.txt", "")
-
-	inputSub := createDir(t, input, "sub")
-	createFileWriteString(t, inputSub, "3.txt", "cat")
-	createFileWriteString(t, inputSub, "4.txt", "aaa")
-
-	inputSubSub := createDir(t, inputSub, "sub")
-	createFileWriteString(t, inputSubSub, "5.txt", "a")
-
-	output := createDir(t, d, "output")
-
-	args := []string{
-		"-i", input,
-		"-s", "a",
-		"-t", "",
-		"-R",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-	{
-		replaced := readString(t, filepath.Join(output, "1.txt"))
-		assert.Equal(t, "bc", replaced)
-	}
-	{
-		replaced := readString(t, filepath.Join(output, "2.txt"))
-		assert.Equal(t, "", replaced)
-	}
-	{
-		replaced := readString(t, filepath.Join(output, "sub", "3.txt"))
-		assert.Equal(t, "ct", replaced)
-	}
-	{
-		replaced := readString(t, filepath.Join(output, "sub", "4.txt"))
-		assert.Equal(t, "", replaced)
-	}
-	{
-		replaced := readString(t, filepath.Join(output, "sub", "sub", "5.txt"))
-		assert.Equal(t, "", replaced)
-	}
-}
-
-func TestRun_Escape_String(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteString(t, d, "input.txt", "1\n2\n")
-	output := filepath.Join(d, "output.txt")
-
-	args := []string{
-		"-i", input,
-		"-s", `\n`,
-		"-t", `\t`,
-		"--escape",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-	replaced := readString(t, output)
-	assert.Equal(t, "1\t2\t", replaced)
-}
-
-func TestRun_Escape_Regex(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteString(t, d, "input.txt", "a　　　")
-	output := filepath.Join(d, "output.txt")
-
-	args := []string{
-		"-i", input,
-		"-r", `\u3000+`,
-		"-t", `\u0020`,
-		"-e",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-	replaced := readString(t, output)
-	assert.Equal(t, "a ", replaced)
-}
-
-func TestRun_Overwrite(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteString(t, d, "input.txt", "aaa")
-
-	args := []string{
-		"-i", input,
-		"-s", "a",
-		"-t", "x",
-		"--overwrite",
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-	replaced := readString(t, input)
-	assert.Equal(t, "xxx", replaced)
-}
-
-func TestRun_Charset_UTF8(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteString(t, d, "input.txt", "あいうえお")
-	output := filepath.Join(d, "output.txt")
-
-	args := []string{
-		"-i", input,
-		"-r", "あ.う",
-		"-t", "",
-		"-c", "utf-8",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-	replaced := readString(t, output)
-	assert.Equal(t, "えお", replaced)
-}
-
-func TestRun_Charset_SJIS(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteBytes(t, d, "input.txt", stringToByte(t, "あいうえお", japanese.ShiftJIS))
-	output := filepath.Join(d, "output.txt")
-
-	args := []string{
-		"-i", input,
-		"-r", "あ.う",
-		"-t", "",
-		"-c", "sjis",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-	replaced := byteToString(t, readBytes(t, output), japanese.ShiftJIS)
-	assert.Equal(t, "えお", replaced)
-}
-
-func TestRun_Charset_Binary(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteBytes(t, d, "input.txt", []byte{0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0xF0})
-	output := filepath.Join(d, "output.txt")
-
-	args := []string{
-		"-i", input,
-		"-s", "x00x01",
-		"-t", "",
-		"-c", "binary",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-	replaced := readBytes(t, output)
-	assert.Equal(t, []byte{0x00, 0x02, 0xF0}, replaced)
-}
-func TestRun_Charset_Invalid(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteString(t, d, "input.txt", "")
-	output := filepath.Join(d, "output.txt")
-
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	stderr := os.Stderr
-	os.Stderr = w
-	defer func() { os.Stderr = stderr }()
-
-	args := []string{
-		"-i", input,
-		"-r", "x",
-		"-t", "",
-		"-c", "xxxx", // 存在しないCharset
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, NG, c)
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	assert.Equal(t, "\nError: htmlindex: invalid encoding name\n", buf.String())
-}
-
-func TestRun_InvalidRegex(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteString(t, d, "input.txt", "")
-	output := filepath.Join(d, "output.txt")
-
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	stderr := os.Stderr
-	os.Stderr = w
-	defer func() { os.Stderr = stderr }()
-
-	args := []string{
-		"-i", input,
-		"-r", "[a", // 不正な正規表現
-		"-t", "",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, NG, c)
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	assert.Equal(t, "\nError: error parsing regexp: missing closing ]: `[a`\n", buf.String())
-}
-
-func TestRun_InvalidEscape_Regex(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteString(t, d, "input.txt", "")
-	output := filepath.Join(d, "output.txt")
-
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	stderr := os.Stderr
-	os.Stderr = w
-	defer func() { os.Stderr = stderr }()
-
-	args := []string{
-		"-i", input,
-		"-r", `\x`, // 不正なエスケープ
-		"-t", "",
-		"-e",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, NG, c)
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	assert.Equal(t, "\nError: --regex is invalid string: \\x\n", buf.String())
-}
-
-func TestRun_InvalidEscape_String(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteString(t, d, "input.txt", "")
-	output := filepath.Join(d, "output.txt")
-
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	stderr := os.Stderr
-	os.Stderr = w
-	defer func() { os.Stderr = stderr }()
-
-	args := []string{
-		"-i", input,
-		"-s", `\x`, // 不正なエスケープ
-		"-t", "",
-		"-e",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, NG, c)
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	assert.Equal(t, "\nError: --string is invalid string: \\x\n", buf.String())
-}
-
-func TestRun_InvalidEscape_Replacement(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createFileWriteString(t, d, "input.txt", "")
-	output := filepath.Join(d, "output.txt")
-
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	stderr := os.Stderr
-	os.Stderr = w
-	defer func() { os.Stderr = stderr }()
-
-	args := []string{
-		"-i", input,
-		"-s", "a",
-		"-t", `\`, // 不正なエスケープ
-		"-e",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, NG, c)
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	assert.Equal(t, "\nError: --replacement is invalid string: \\\n", buf.String())
-}
-
-func TestRun_InputNotFound(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := filepath.Join(d, "input") // 存在しない
-	output := filepath.Join(d, "output")
-
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	stderr := os.Stderr
-	os.Stderr = w
-	defer func() { os.Stderr = stderr }()
-
-	args := []string{
-		"-i", input,
-		"-s", "a",
-		"-t", "",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, NG, c)
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	assert.Contains(t, buf.String(), "Error:") // 実行環境によってファイルが存在しない場合のエラーメッセージが異なるので、Errorだけで判定
-}
-
-func TestRun_OutputNotFound(t *testing.T) {
-
-	// ARRANGE
-	d := createTempDir(t)
-	defer os.RemoveAll(d)
-
-	input := createDir(t, d, "input")
-	output := filepath.Join(d, "a", "b") // 親ディレクトリ自体が無い
-
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	stderr := os.Stderr
-	os.Stderr = w
-	defer func() { os.Stderr = stderr }()
-
-	args := []string{
-		"-i", input,
-		"-s", "a",
-		"-t", "",
-		"-o", output,
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, NG, c)
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	assert.Contains(t, buf.String(), "Error:") // 実行環境によってファイルが存在しない場合のエラーメッセージが異なるので、Errorだけで判定
-}
-
-func TestRun_Help(t *testing.T) {
-
-	// ARRANGE
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	stdout := os.Stdout
-	os.Stdout = w
-	defer func() { os.Stdout = stdout }()
-
-	args := []string{
-		"-h",
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, OK, c)
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	assert.Contains(t, buf.String(), "Usage: rcf")
-}
-
-func TestRun_InvalidArgs_Empty(t *testing.T) {
-
-	// ARRANGE
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	stderr := os.Stderr
-	os.Stderr = w
-	defer func() { os.Stderr = stderr }()
-
-	args := []string{}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, NG, c)
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	assert.Contains(t, buf.String(), "Usage: rcf")
-}
-
-func TestRun_InvalidArgs_Unknown(t *testing.T) {
-
-	// ARRANGE
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	stderr := os.Stderr
-	os.Stderr = w
-	defer func() { os.Stderr = stderr }()
-
-	args := []string{
-		"-x",
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, NG, c)
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	assert.Contains(t, buf.String(), "unknown shorthand flag: 'x' in -x")
-}
-
-func TestRun_InvalidArgs_InputEmpty(t *testing.T) {
-
-	// ARRANGE
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	stderr := os.Stderr
-	os.Stderr = w
-	defer func() { os.Stderr = stderr }()
-
-	args := []string{
-		"-s", "a",
-		"-t", "",
-		"-o", "out",
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, NG, c)
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	assert.Contains(t, buf.String(), "Usage: rcf")
-}
-
-func TestRun_InvalidArgs_StringAndRegexEmpty(t *testing.T) {
-
-	// ARRANGE
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	stderr := os.Stderr
-	os.Stderr = w
-	defer func() { os.Stderr = stderr }()
-
-	args := []string{
-		"-i", "in",
-		"-t", "",
-		"-o", "out",
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, NG, c)
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	assert.Contains(t, buf.String(), "Usage: rcf")
-}
-
-func TestRun_InvalidArgs_OutputAndOverwriteEmpty(t *testing.T) {
-
-	// ARRANGE
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	stderr := os.Stderr
-	os.Stderr = w
-	defer func() { os.Stderr = stderr }()
-
-	args := []string{
-		"-i", "in",
-		"-s", "x",
-		"-t", "",
-	}
-
-	// ACT
-	c := run(args)
-
-	// ASSERT
-	require.Equal(t, NG, c)
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	assert.Contains(t, buf.String(), "Usage: rcf")
-}
-
-//////////////////////////////////////////////////////////
-
-func createFileWriteBytes(t *testing.T, dir string, name string, content []byte) string {
-
-	file, err := os.Create(filepath.Join(dir, name))
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	_, err = file.Write(content)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	err = file.Close()
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	return file.Name()
-}
-
-func createFileWriteString(t *testing.T, dir string, name string, content string) string {
-
-	return createFileWriteBytes(t, dir, name, []byte(content))
-}
-
-func createTempDir(t *testing.T) string {
-
-	tempDir, err := os.MkdirTemp("", "rcf")
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	return tempDir
-}
-
-func createDir(t *testing.T, parent string, name string) string {
-
-	dir := filepath.Join(parent, name)
-	err := os.Mkdir(dir, os.ModePerm)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	return dir
-}
-
-func readBytes(t *testing.T, name string) []byte {
-
-	bo, err := os.ReadFile(name)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	return bo
-}
-
-func readString(t *testing.T, name string) string {
-
-	bo := readBytes(t, name)
-	return string(bo)
-}
-
-func byteToString(t *testing.T, bytes []byte, enc encoding.Encoding) string {
-	decoded, err := enc.NewDecoder().Bytes(bytes)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	return string(decoded)
-}
-
-func stringToByte(t *testing.T, str string, enc encoding.Encoding) []byte {
-	encoded, err := enc.NewEncoder().Bytes([]byte(str))
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	return encoded
-}
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestMain(m *testing.M) {
+
+	// 実行環境にある設定ファイルの影響を受けないように
+	configDir = ""
+
+	os.Exit(m.Run())
+}
+
+func TestRun_File_Regex(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "abc\nabc\naa")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-r", "a$",
+		"-t", "x",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "abc\nabc\nax", replaced)
+}
+
+func TestRun_File_String(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "aa.ab.ac.ad.a.b.c.d")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-s", "a.",
+		"-t", "xx",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "axxab.ac.ad.xxb.c.d", replaced)
+}
+
+func TestRun_Dir_Regex(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createDir(t, d, "input")
+
+	createFileWriteString(t, input, "input1.txt", "abc\nabc\naa")
+	createFileWriteString(t, input, "input2.txt", "a")
+	createFileWriteString(t, input, "input3.txt", "ax")
+
+	output := createDir(t, d, "output")
+
+	args := []string{
+		"-i", input,
+		"-r", "a$",
+		"-t", "x",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	{
+		replaced := readString(t, filepath.Join(output, "input1.txt"))
+		assert.Equal(t, "abc\nabc\nax", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "input2.txt"))
+		assert.Equal(t, "x", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "input3.txt"))
+		assert.Equal(t, "ax", replaced)
+	}
+}
+
+func TestRun_File_Regex_Japanese(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "あいうえおかきくけこ")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-r", "あ.{4}",
+		"-t", "",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "かきくけこ", replaced)
+}
+
+func TestRun_Dir_String(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createDir(t, d, "input")
+
+	createFileWriteString(t, input, "input1.txt", "abc\na.c\naa")
+	createFileWriteString(t, input, "input2.txt", "")
+	createFileWriteString(t, input, "input3.txt", "a.c")
+
+	output := createDir(t, d, "output")
+
+	args := []string{
+		"-i", input,
+		"-s", "a.c",
+		"-t", "",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	{
+		replaced := readString(t, filepath.Join(output, "input1.txt"))
+		assert.Equal(t, "abc\n\naa", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "input2.txt"))
+		assert.Equal(t, "", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "input3.txt"))
+		assert.Equal(t, "", replaced)
+	}
+}
+
+func TestRun_Dir_CreateOutputDir(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createDir(t, d, "input")
+
+	createFileWriteString(t, input, "input1.txt", "abc\nabc\nabc")
+
+	output := filepath.Join(d, "output") // 出力ディレクトリは存在しない状態
+
+	args := []string{
+		"-i", input,
+		"-r", "(?m)c$",
+		"-t", "x",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, filepath.Join(output, "input1.txt"))
+	assert.Equal(t, "abx\nabx\nabx", replaced)
+}
+
+func TestRun_Dir_Recursive(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createDir(t, d, "input")
+	createFileWriteString(t, input, "1.txt", "abc")
+	createFileWriteString(t, input, "2.txt", "")
+
+	inputSub := createDir(t, input, "sub")
+	createFileWriteString(t, inputSub, "3.txt", "cat")
+	createFileWriteString(t, inputSub, "4.txt", "aaa")
+
+	inputSubSub := createDir(t, inputSub, "sub")
+	createFileWriteString(t, inputSubSub, "5.txt", "a")
+
+	output := createDir(t, d, "output")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "",
+		"-R",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	{
+		replaced := readString(t, filepath.Join(output, "1.txt"))
+		assert.Equal(t, "bc", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "2.txt"))
+		assert.Equal(t, "", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "sub", "3.txt"))
+		assert.Equal(t, "ct", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "sub", "4.txt"))
+		assert.Equal(t, "", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "sub", "sub", "5.txt"))
+		assert.Equal(t, "", replaced)
+	}
+}
+
+func TestRun_Multiline(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "abc\nabc\nabc")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-r", "^a|c$",
+		"-t", "x",
+		"--multiline",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "xbx\nxbx\nxbx", replaced)
+}
+
+func TestRun_DotAll(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "a/*\nb\n*/c")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-r", `/\*.*\*/`,
+		"-t", "",
+		"--dotall",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "ac", replaced)
+}
+
+func TestRun_LineMode(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "abc\r\n\r\nabc\r\n")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-r", "^",
+		"-t", "> ",
+		"--line-mode",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "> abc\r\n> \r\n> abc\r\n", replaced)
+}
+
+func TestRun_Lines(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "a\na\na\na\na\n")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "x",
+		"--lines", "2:4",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "a\nx\nx\nx\na\n", replaced)
+}
+
+func TestRun_Lines_Invalid(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "")
+	output := filepath.Join(d, "output.txt")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "x",
+		"--lines", "5:2",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: --lines is invalid range: 5:2\n", buf.String())
+}
+
+func TestRun_Between(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "a\n// BEGIN GENERATED\na\n// END GENERATED\na\n")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "x",
+		"--between-start", "// BEGIN GENERATED",
+		"--between-end", "// END GENERATED",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "a\n// BEGIN GENERATED\nx\n// END GENERATED\na\n", replaced)
+}
+
+func TestRun_Between_Inclusive(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "a[a]a")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "x",
+		"--between-start", `\[a`,
+		"--between-end", `\]`,
+		"--inclusive",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "a[x]a", replaced)
+}
+
+func TestRun_AfterBefore(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "a\n[main]\na\n[sub]\na\n")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "x",
+		"--after", `\[main\]`,
+		"--before", `\[sub\]`,
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "a\n[main]\nx\n[sub]\na\n", replaced)
+}
+
+func TestRun_Occurrence(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "version=1\nversion=1\nversion=1\n")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-s", "version=1",
+		"-t", "version=2",
+		"--occurrence", "-1",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "version=1\nversion=1\nversion=2\n", replaced)
+}
+
+func TestRun_MaxCount_MaxTotal(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createDir(t, d, "input")
+
+	createFileWriteString(t, input, "input1.txt", "aaa")
+	createFileWriteString(t, input, "input2.txt", "aaa")
+	createFileWriteString(t, input, "input3.txt", "aaa")
+
+	output := createDir(t, d, "output")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "x",
+		"--max-count", "2",
+		"--max-total", "3",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	{
+		replaced := readString(t, filepath.Join(output, "input1.txt"))
+		assert.Equal(t, "xxa", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "input2.txt"))
+		assert.Equal(t, "xaa", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "input3.txt"))
+		assert.Equal(t, "aaa", replaced)
+	}
+}
+
+func TestRun_Operation_DeleteLine(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "a\n# b\nc\n# d\n")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-r", "^#",
+		"-p", "delete-line",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "a\nc\n", replaced)
+}
+
+func TestRun_Operation_InsertBefore(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "a\nb\nc\n")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-s", "b",
+		"-t", "x",
+		"-p", "insert-before",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "a\nx\nb\nc\n", replaced)
+}
+
+func TestRun_Operation_InsertAfter(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "import a\nimport b\n\nmain\n")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-r", "^import ",
+		"-t", "import x",
+		"-p", "insert-after",
+		"--occurrence", "-1",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "import a\nimport b\nimport x\n\nmain\n", replaced)
+}
+
+func TestRun_Operation_EnsurePresent(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createDir(t, d, "input")
+
+	createFileWriteString(t, input, "input1.txt", "host=a\nport=80\n")
+	createFileWriteString(t, input, "input2.txt", "host=a\n")
+
+	output := createDir(t, d, "output")
+
+	args := []string{
+		"-i", input,
+		"-r", "^port=",
+		"-t", "port=8080",
+		"-p", "ensure-present",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	{
+		replaced := readString(t, filepath.Join(output, "input1.txt"))
+		assert.Equal(t, "host=a\nport=8080\n", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "input2.txt"))
+		assert.Equal(t, "host=a\nport=8080\n", replaced)
+	}
+}
+
+func TestRun_Operation_EnsureAbsent(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "host=a\nport=80\n")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-s", "port=",
+		"-p", "ensure-absent",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "host=a\n", replaced)
+}
+
+func TestRun_Operation_Invalid(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "")
+	output := filepath.Join(d, "output.txt")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-p", "xxxx",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: unknown operation \"xxxx\"\n", buf.String())
+}
+
+func TestRun_If(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createDir(t, d, "input")
+
+	createFileWriteString(t, input, "input1.txt", "// marker\na")
+	createFileWriteString(t, input, "input2.txt", "a")
+
+	output := createDir(t, d, "output")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "x",
+		"--if", "^// marker",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	{
+		replaced := readString(t, filepath.Join(output, "input1.txt"))
+		assert.Equal(t, "// mxrker\nx", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "input2.txt"))
+		assert.Equal(t, "a", replaced)
+	}
+}
+
+func TestRun_Unless(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createDir(t, d, "input")
+
+	createFileWriteString(t, input, "input1.txt", "abc")
+	createFileWriteString(t, input, "input2.txt", "prefix-abc")
+
+	output := createDir(t, d, "output")
+
+	args := []string{
+		"-i", input,
+		"-r", "^",
+		"-t", "prefix-",
+		"--unless", "^prefix-",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	{
+		replaced := readString(t, filepath.Join(output, "input1.txt"))
+		assert.Equal(t, "prefix-abc", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "input2.txt"))
+		assert.Equal(t, "prefix-abc", replaced)
+	}
+}
+
+func TestRun_CheckIdempotent(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createDir(t, d, "input")
+
+	createFileWriteString(t, input, "input1.txt", "aaa")
+	createFileWriteString(t, input, "input2.txt", "bbb")
+
+	output := createDir(t, d, "output")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "aa",
+		"--check-idempotent",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	{
+		replaced := readString(t, filepath.Join(output, "input1.txt"))
+		assert.Equal(t, "aaaaaa", replaced)
+	}
+	{
+		replaced := readString(t, filepath.Join(output, "input2.txt"))
+		assert.Equal(t, "bbb", replaced)
+	}
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "Warning: "+filepath.Join(input, "input1.txt")+" is not idempotent. Replacing again would change the result.\n", buf.String())
+}
+
+func TestRun_FilesWith(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createDir(t, d, "input")
+
+	createFileWriteString(t, input, "input1.txt", "// Copyright\na")
+	createFileWriteString(t, input, "input2.txt", "a")
+
+	output := createDir(t, d, "output")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "x",
+		"--files-with", "(?m)^// Copyright",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	{
+		replaced := readString(t, filepath.Join(output, "input1.txt"))
+		assert.Equal(t, "// Copyright\nx", replaced)
+	}
+	assert.NoFileExists(t, filepath.Join(output, "input2.txt"))
+}
+
+func TestRun_FilesWithout(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createDir(t, d, "input")
+
+	createFileWriteString(t, input, "input1.txt", "// Copyright\na")
+	createFileWriteString(t, input, "input2.txt", "a")
+
+	output := createDir(t, d, "output")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "x",
+		"--files-without", "(?m)^// Copyright",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	assert.NoFileExists(t, filepath.Join(output, "input1.txt"))
+	{
+		replaced := readString(t, filepath.Join(output, "input2.txt"))
+		assert.Equal(t, "x", replaced)
+	}
+}
+
+func TestRun_Scope_Comments(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	createFileWriteString(t, d, "main.go", "// TODO: foo\nfunc foo() string { return \"TODO\" } // TODO\n")
+	createFileWriteString(t, d, "run.sh", "# TODO: foo\necho \"TODO\" # TODO\n")
+	createFileWriteString(t, d, "memo.txt", "TODO\n")
+	output := filepath.Join(d, "output")
+
+	args := []string{
+		"-i", d,
+		"-s", "TODO",
+		"-t", "FIXME",
+		"--scope", "comments",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	assert.Equal(t, "// FIXME: foo\nfunc foo() string { return \"TODO\" } // FIXME\n", readString(t, filepath.Join(output, "main.go")))
+	assert.Equal(t, "# FIXME: foo\necho \"TODO\" # FIXME\n", readString(t, filepath.Join(output, "run.sh")))
+	// 対応していない言語は対象外
+	assert.Equal(t, "TODO\n", readString(t, filepath.Join(output, "memo.txt")))
+}
+
+func TestRun_Scope_Code(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.js", "const foo = 'foo'; // foo\nfoo();\n")
+	output := filepath.Join(d, "output.js")
+
+	args := []string{
+		"-i", input,
+		"-s", "foo",
+		"-t", "bar",
+		"--scope", "code",
+		"--max-count", "1",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "const bar = 'foo'; // foo\nfoo();\n", replaced)
+}
+
+func TestRun_Scope_Invalid(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.go", "")
+	output := filepath.Join(d, "output.go")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"--scope", "xxxx",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: unknown scope \"xxxx\"\n", buf.String())
+}
+
+func TestRun_GoRename(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := filepath.Join(d, "input")
+	require.NoError(t, os.MkdirAll(filepath.Join(input, "util"), os.ModePerm))
+	createFileWriteString(t, input, "go.mod", "module example.com/app\n")
+	createFileWriteString(t, filepath.Join(input, "util"), "util.go", "package util\n\nfunc Old() string { return \"Old\" }\n")
+	createFileWriteString(t, input, "main.go", "package main\n\nimport \"example.com/app/util\"\n\nfunc Old() {}\n\nfunc main() {\n\tOld()\n\tprintln(util.Old())\n}\n")
+	createFileWriteString(t, input, "README.md", "util.Old\n")
+	output := filepath.Join(d, "output")
+
+	args := []string{
+		"-i", input,
+		"-s", "util.Old",
+		"-t", "New",
+		"--go-rename",
+		"-R",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	assert.Equal(t, "package util\n\nfunc New() string { return \"Old\" }\n", readString(t, filepath.Join(output, "util", "util.go")))
+	assert.Equal(t, "package main\n\nimport \"example.com/app/util\"\n\nfunc Old() {}\n\nfunc main() {\n\tOld()\n\tprintln(util.New())\n}\n", readString(t, filepath.Join(output, "main.go")))
+	// Goのファイル以外や変更が無いファイルはそのまま出力
+	assert.Equal(t, "util.Old\n", readString(t, filepath.Join(output, "README.md")))
+	assert.Equal(t, "module example.com/app\n", readString(t, filepath.Join(output, "go.mod")))
+}
+
+func TestRun_GoRename_Overwrite(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	createFileWriteString(t, d, "go.mod", "module example.com/app\n")
+	createFileWriteString(t, d, "a.go", "package main\n\nfunc Old() {}\n")
+	createFileWriteString(t, d, "main.go", "package main\n\nfunc main() {}\n")
+	createFileWriteString(t, d, "README.md", "Old\n")
+
+	modTime := time.Date(2021, 5, 1, 10, 20, 30, 0, time.Local)
+	for _, name := range []string{"go.mod", "a.go", "main.go", "README.md"} {
+		require.NoError(t, os.Chtimes(filepath.Join(d, name), modTime, modTime))
+	}
+
+	args := []string{
+		"-i", d,
+		"-s", "Old",
+		"-t", "New",
+		"--go-rename",
+		"-O",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	assert.Equal(t, "package main\n\nfunc New() {}\n", readString(t, filepath.Join(d, "a.go")))
+
+	// 変更の無いファイルは書き込まない
+	for _, name := range []string{"go.mod", "main.go", "README.md"} {
+		info, err := os.Stat(filepath.Join(d, name))
+		require.NoError(t, err)
+		assert.True(t, modTime.Equal(info.ModTime()), name)
+	}
+}
+
+func TestRun_GoRename_DryRun(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	createFileWriteString(t, d, "go.mod", "module example.com/app\n")
+	createFileWriteString(t, d, "b.go", "package main\n\nfunc Old() {}\n")
+	createFileWriteString(t, d, "a.go", "package main\n\nfunc main() { Old() }\n")
+	createFileWriteString(t, d, "c.go", "package main\n\nfunc c() {}\n")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	args := []string{
+		"-i", d,
+		"-s", "Old",
+		"-t", "New",
+		"--go-rename",
+		"--dry-run",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, filepath.Join(d, "a.go")+"\n"+filepath.Join(d, "b.go")+"\n", buf.String())
+
+	// 書き込まれないこと
+	assert.Equal(t, "package main\n\nfunc Old() {}\n", readString(t, filepath.Join(d, "b.go")))
+}
+
+func TestRun_GoRename_File(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "main.go", "package main\n")
+	output := filepath.Join(d, "output.go")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "Old",
+		"-t", "New",
+		"--go-rename",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: --go-rename requires a directory as input\n", buf.String())
+}
+
+func TestRun_GoRename_Config(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	createFileWriteString(t, d, ".rcf.yaml", "charset: sjis\nlines: \"1:2\"\n")
+	input := createDir(t, d, "input")
+	createFileWriteString(t, input, "a.go", "package main\n\nfunc Old() {}\n")
+	defer setConfigDir(d)()
+
+	args := []string{
+		"-i", input,
+		"-s", "Old",
+		"-t", "New",
+		"--go-rename",
+		"-O",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+
+	// 設定ファイルの値は識別子の変更では使わない
+	assert.Equal(t, "package main\n\nfunc New() {}\n", readString(t, filepath.Join(input, "a.go")))
+}
+
+func TestRun_GoRename_UnsupportedOption(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := filepath.Join(d, "input")
+	require.NoError(t, os.MkdirAll(input, os.ModePerm))
+	createFileWriteString(t, input, "main.go", "package main\n\nfunc Old() {}\n")
+	output := filepath.Join(d, "output")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "Old",
+		"-t", "New",
+		"--go-rename",
+		"--lines", "1:2",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: --go-rename cannot be used with --lines\n", buf.String())
+	assert.NoDirExists(t, output)
+}
+
+func TestRun_RenamePaths(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := filepath.Join(d, "input")
+	require.NoError(t, os.MkdirAll(filepath.Join(input, "foo-dir"), os.ModePerm))
+	createFileWriteString(t, input, "foo.txt", "foo\n")
+	createFileWriteString(t, filepath.Join(input, "foo-dir"), "foo-sub.txt", "foo\n")
+	output := filepath.Join(d, "output")
+
+	args := []string{
+		"-i", input,
+		"-s", "foo",
+		"-t", "bar",
+		"--rename-paths",
+		"-R",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	assert.Equal(t, "bar\n", readString(t, filepath.Join(output, "bar.txt")))
+	assert.Equal(t, "bar\n", readString(t, filepath.Join(output, "bar-dir", "bar-sub.txt")))
+	assert.NoFileExists(t, filepath.Join(output, "foo.txt"))
+}
+
+func TestRun_RenamePaths_Overwrite(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(d, "foo-dir"), os.ModePerm))
+	createFileWriteString(t, d, "foo.txt", "foo\n")
+	createFileWriteString(t, filepath.Join(d, "foo-dir"), "foo-sub.txt", "foo\n")
+
+	args := []string{
+		"-i", d,
+		"-s", "foo",
+		"-t", "bar",
+		"--paths-only",
+		"-R",
+		"-O",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	// 内容はそのまま
+	assert.Equal(t, "foo\n", readString(t, filepath.Join(d, "bar.txt")))
+	assert.Equal(t, "foo\n", readString(t, filepath.Join(d, "bar-dir", "bar-sub.txt")))
+	assert.NoFileExists(t, filepath.Join(d, "foo.txt"))
+	assert.NoDirExists(t, filepath.Join(d, "foo-dir"))
+}
+
+func TestRun_RenamePaths_File(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "foo.txt", "foo\n")
+
+	args := []string{
+		"-i", input,
+		"-s", "foo",
+		"-t", "bar",
+		"--rename-paths",
+		"-O",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	assert.Equal(t, "bar\n", readString(t, filepath.Join(d, "bar.txt")))
+	assert.NoFileExists(t, input)
+}
+
+func TestRun_RenamePaths_Collision(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	createFileWriteString(t, d, "a1.txt", "")
+	createFileWriteString(t, d, "a2.txt", "")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", d,
+		"-r", "[0-9]",
+		"-t", "",
+		"--paths-only",
+		"-O",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: "+filepath.Join(d, "a1.txt")+" and "+filepath.Join(d, "a2.txt")+" would have the same name a.txt\n", buf.String())
+
+	// 何も変更されていないこと
+	assert.FileExists(t, filepath.Join(d, "a1.txt"))
+	assert.FileExists(t, filepath.Join(d, "a2.txt"))
+}
+
+func TestRun_RenamePaths_DryRun(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(d, "foo-dir"), os.ModePerm))
+	createFileWriteString(t, d, "foo.txt", "foo\n")
+	createFileWriteString(t, d, "other.txt", "foo\n")
+	createFileWriteString(t, filepath.Join(d, "foo-dir"), "foo-sub.txt", "foo\n")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	args := []string{
+		"-i", d,
+		"-s", "foo",
+		"-t", "bar",
+		"--rename-paths",
+		"-R",
+		"--dry-run",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t,
+		filepath.Join(d, "foo-dir")+" -> "+filepath.Join(d, "bar-dir")+"\n"+
+			filepath.Join(d, "foo-dir", "foo-sub.txt")+" -> "+filepath.Join(d, "bar-dir", "bar-sub.txt")+"\n"+
+			filepath.Join(d, "foo.txt")+" -> "+filepath.Join(d, "bar.txt")+"\n",
+		buf.String())
+
+	// 何も変更されていないこと
+	assert.Equal(t, "foo\n", readString(t, filepath.Join(d, "foo.txt")))
+	assert.Equal(t, "foo\n", readString(t, filepath.Join(d, "other.txt")))
+	assert.Equal(t, "foo\n", readString(t, filepath.Join(d, "foo-dir", "foo-sub.txt")))
+	assert.NoDirExists(t, filepath.Join(d, "bar-dir"))
+}
+
+func TestRun_DryRun_WithoutRenamePaths(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "foo.txt", "foo\n")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "foo",
+		"-t", "bar",
+		"--dry-run",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: --dry-run requires --rename-paths, --paths-only or --go-rename\n", buf.String())
+	assert.Equal(t, "foo\n", readString(t, input))
+}
+
+func TestRun_Mirror(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := filepath.Join(d, "input")
+	require.NoError(t, os.MkdirAll(filepath.Join(input, "sub"), os.ModePerm))
+	createFileWriteString(t, input, "a.txt", "a\n")
+	createFileWriteString(t, input, "b.txt", "b a\n")
+	script := createFileWriteString(t, filepath.Join(input, "sub"), "run.sh", "a\n")
+	require.NoError(t, os.Chmod(script, 0755))
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(input, "link.txt")))
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(input, "b.txt"), modTime, modTime))
+	require.NoError(t, os.Chtimes(filepath.Join(input, "sub"), modTime, modTime))
+
+	output := filepath.Join(d, "output")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "x",
+		"--files-with", "^a",
+		"--mirror",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	assert.Equal(t, "x\n", readString(t, filepath.Join(output, "a.txt")))
+
+	// 対象外のファイルはそのままコピー
+	assert.Equal(t, "b a\n", readString(t, filepath.Join(output, "b.txt")))
+	bInfo, err := os.Stat(filepath.Join(output, "b.txt"))
+	require.NoError(t, err)
+	assert.True(t, modTime.Equal(bInfo.ModTime()))
+
+	// 再帰的にたどらないディレクトリもコピー
+	assert.Equal(t, "a\n", readString(t, filepath.Join(output, "sub", "run.sh")))
+	scriptInfo, err := os.Stat(filepath.Join(output, "sub", "run.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), scriptInfo.Mode().Perm())
+	subInfo, err := os.Stat(filepath.Join(output, "sub"))
+	require.NoError(t, err)
+	assert.True(t, modTime.Equal(subInfo.ModTime()))
+
+	// シンボリックリンクはリンクのまま
+	link, err := os.Readlink(filepath.Join(output, "link.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt", link)
+}
+
+func TestRun_Mirror_Recursive(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := filepath.Join(d, "input")
+	require.NoError(t, os.MkdirAll(filepath.Join(input, "sub"), 0750))
+	require.NoError(t, os.Chmod(filepath.Join(input, "sub"), 0750))
+	createFileWriteString(t, filepath.Join(input, "sub"), "a.txt", "a\n")
+	require.NoError(t, os.Symlink("sub", filepath.Join(input, "sublink")))
+	output := filepath.Join(d, "output")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "x",
+		"--mirror",
+		"-R",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	assert.Equal(t, "x\n", readString(t, filepath.Join(output, "sub", "a.txt")))
+
+	subInfo, err := os.Stat(filepath.Join(output, "sub"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), subInfo.Mode().Perm())
+
+	link, err := os.Readlink(filepath.Join(output, "sublink"))
+	require.NoError(t, err)
+	assert.Equal(t, "sub", link)
+}
+
+func TestRun_Mirror_RenamePaths(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := filepath.Join(d, "input")
+	require.NoError(t, os.MkdirAll(filepath.Join(input, "conf"), os.ModePerm))
+	createFileWriteString(t, filepath.Join(input, "conf"), "old.conf", "old\n")
+	require.NoError(t, os.Symlink(filepath.Join("conf", "old.conf"), filepath.Join(input, "current.conf")))
+	output := filepath.Join(d, "output")
+
+	args := []string{
+		"-i", input,
+		"-s", "old",
+		"-t", "new",
+		"--rename-paths",
+		"--mirror",
+		"-R",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+
+	// リンク先も置換後の名前になるので、リンクが切れない
+	link, err := os.Readlink(filepath.Join(output, "current.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("conf", "new.conf"), link)
+	assert.Equal(t, "new\n", readString(t, filepath.Join(output, "current.conf")))
+}
+
+func TestRun_Format_JSON(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.json", `{"image": "app:1.0", "app:1.0": ["app:1.0"]}`)
+	output := filepath.Join(d, "output.json")
+
+	args := []string{
+		"-i", input,
+		"-s", "app:1.0",
+		"-t", "app:2.0",
+		"--format", "json",
+		"--path", "$.image",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, `{"image": "app:2.0", "app:1.0": ["app:1.0"]}`, replaced)
+}
+
+func TestRun_Format_YAML(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.yaml", "# app:1.0\nservices:\n  web:\n    image: app:1.0 # app:1.0\n")
+	output := filepath.Join(d, "output.yaml")
+
+	args := []string{
+		"-i", input,
+		"-s", "app:1.0",
+		"-t", "app:2.0",
+		"--format", "yaml",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "# app:1.0\nservices:\n  web:\n    image: app:2.0 # app:1.0\n", replaced)
+}
+
+func TestRun_CSV_SJIS(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.csv", stringToByte(t, "名前,URL\r\n\"あ,い\",http://a\r\nhttp://b,\"http://b\nc\"\r\n", japanese.ShiftJIS))
+	output := filepath.Join(d, "output.csv")
+
+	args := []string{
+		"-i", input,
+		"-s", "http:",
+		"-t", "https:",
+		"--csv",
+		"--column", "URL",
+		"-c", "sjis",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := byteToString(t, readBytes(t, output), japanese.ShiftJIS)
+	assert.Equal(t, "名前,URL\r\n\"あ,い\",https://a\r\nhttp://b,\"https://b\nc\"\r\n", replaced)
+}
+
+func TestRun_CSV_TSV(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.tsv", "a\ta\ta\na\ta\ta\n")
+	output := filepath.Join(d, "output.tsv")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "x\ty",
+		"--format", "csv",
+		"--csv-delimiter", `\t`,
+		"--column", "1",
+		"--column", "3",
+		"--no-header",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "\"x\ty\"\ta\t\"x\ty\"\n\"x\ty\"\ta\t\"x\ty\"\n", replaced)
+}
+
+func TestRun_Format_HTML(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.html", "<div class=\"a\" title=\"a\"><p>a</p><script>a</script></div>\n<p>a</p>\n")
+	output := filepath.Join(d, "output.html")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "<b>",
+		"--format", "html",
+		"--selector", "div.a",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "<div class=\"a\" title=\"a\"><p>&lt;b&gt;</p><script>a</script></div>\n<p>a</p>\n", replaced)
+}
+
+func TestRun_Format_HTML_Attr(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.html", "<a href=\"http://example.com\" title=\"http://example.com\">http://example.com</a>\n")
+	output := filepath.Join(d, "output.html")
+
+	args := []string{
+		"-i", input,
+		"-s", "http:",
+		"-t", "https:",
+		"--format", "html",
+		"--attr", "href",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "<a href=\"https://example.com\" title=\"http://example.com\">http://example.com</a>\n", replaced)
+}
+
+func TestRun_Format_XML_DeclaredCharset(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.xml", stringToByte(t, "<?xml version=\"1.0\" encoding=\"Shift_JIS\"?>\n<!-- あ -->\n<a>あいう</a>\n", japanese.ShiftJIS))
+	output := filepath.Join(d, "output.xml")
+
+	args := []string{
+		"-i", input,
+		"-s", "あ",
+		"-t", "え",
+		"--format", "xml",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := byteToString(t, readBytes(t, output), japanese.ShiftJIS)
+	assert.Equal(t, "<?xml version=\"1.0\" encoding=\"Shift_JIS\"?>\n<!-- あ -->\n<a>えいう</a>\n", replaced)
+}
+
+func TestRun_Format_Properties(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.properties", stringToByte(t, "# greeting\ngreeting=\\u3053\\u3093\\u306B\\u3061\\u306F\nname=caf\u00e9\n", charmap.ISO8859_1))
+	output := filepath.Join(d, "output.properties")
+
+	args := []string{
+		"-i", input,
+		"-s", "こんにちは",
+		"-t", "おはよう",
+		"--format", "properties",
+		"--key", "greeting",
+		"-c", "iso-8859-1",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := byteToString(t, readBytes(t, output), charmap.ISO8859_1)
+	assert.Equal(t, "# greeting\ngreeting=\\u304A\\u306F\\u3088\\u3046\nname=caf\u00e9\n", replaced)
+}
+
+func TestRun_Format_Properties_DefaultCharset(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.properties", []byte("a=caf\xe9\nb=x\n"))
+
+	args := []string{
+		"-i", input,
+		"-s", "x",
+		"-t", "y",
+		"--format", "properties",
+		"--key", "b",
+		"-O",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+
+	// -c を指定しない場合はISO-8859-1として扱うので、対象外の値は壊れない
+	assert.Equal(t, []byte("a=caf\xe9\nb=y\n"), readBytes(t, input))
+}
+
+func TestRun_Format_Properties_Latin1(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.properties", []byte("name=caf\xe9\n"))
+
+	args := []string{
+		"-i", input,
+		"-s", "caf",
+		"-t", "日本",
+		"--format", "properties",
+		"-O",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+
+	// ISO-8859-1で表せない文字はエスケープ
+	assert.Equal(t, []byte("name=\\u65E5\\u672C\xe9\n"), readBytes(t, input))
+}
+
+func TestRun_Format_INI(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.ini", "[app]\nhost=localhost\n\n[database]\n; host=localhost\nhost=localhost\n")
+	output := filepath.Join(d, "output.ini")
+
+	args := []string{
+		"-i", input,
+		"-r", "^.*$",
+		"-t", "db.example.com",
+		"--format", "ini",
+		"--section", "database",
+		"--key", "host",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "[app]\nhost=localhost\n\n[database]\n; host=localhost\nhost=db.example.com\n", replaced)
+}
+
+func TestRun_Format_Dotenv(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, ".env", "# DEBUG=true\nDEBUG=true # local only\nexport VERBOSE=true\n")
+	output := filepath.Join(d, "output.env")
+
+	args := []string{
+		"-i", input,
+		"-s", "true",
+		"-t", "false",
+		"--format", "dotenv",
+		"--key", "DEBUG",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "# DEBUG=true\nDEBUG=false # local only\nexport VERBOSE=true\n", replaced)
+}
+
+func TestRun_Format_Invalid(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "")
+	output := filepath.Join(d, "output.txt")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"--format", "xxxx",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: unknown format \"xxxx\"\n", buf.String())
+}
+
+func TestRun_Format_ParseError(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.json", `{"a":`)
+	output := filepath.Join(d, "output.json")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"--format", "json",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Contains(t, buf.String(), "\nError: "+input+": ")
+}
+
+func TestRun_Escape_String(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "1\n2\n")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-s", `\n`,
+		"-t", `\t`,
+		"--escape",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "1\t2\t", replaced)
+}
+
+func TestRun_Escape_Regex(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "a　　　")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-r", `\u3000+`,
+		"-t", `\u0020`,
+		"-e",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "a ", replaced)
+}
+
+func TestRun_ReplacementFile(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "// old header\nmain\n")
+	targetFile := createFileWriteString(t, d, "target.txt", "// old header\n")
+	replacementFile := createFileWriteString(t, d, "replacement.txt", "// new header\n// \\n $1\n")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"--target-file", targetFile,
+		"--replacement-file", replacementFile,
+		"-e",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "// new header\n// \\n $1\nmain\n", replaced)
+}
+
+func TestRun_RegexFile_SJIS(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.txt", stringToByte(t, "あいう", japanese.ShiftJIS))
+	regexFile := createFileWriteBytes(t, d, "regex.txt", stringToByte(t, "い.", japanese.ShiftJIS))
+	replacementFile := createFileWriteBytes(t, d, "replacement.txt", stringToByte(t, "え", japanese.ShiftJIS))
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"--regex-file", regexFile,
+		"--replacement-file", replacementFile,
+		"-c", "sjis",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := byteToString(t, readBytes(t, output), japanese.ShiftJIS)
+	assert.Equal(t, "あえ", replaced)
+}
+
+func TestRun_ReplacementFile_NotFound(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "")
+	output := filepath.Join(d, "output.txt")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"--replacement-file", filepath.Join(d, "replacement.txt"), // 存在しない
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Contains(t, buf.String(), "Error:")
+}
+
+func TestRun_EnvReplacement(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "image: app:1.0")
+	output := filepath.Join(d, "output.txt")
+
+	os.Setenv("RCF_TEST_TAG", "2.0")
+	defer os.Unsetenv("RCF_TEST_TAG")
+
+	args := []string{
+		"-i", input,
+		"-r", "app:(.*)",
+		"-t", "app:${env:RCF_TEST_TAG} # was $1",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "image: app:2.0 # was 1.0", replaced)
+}
+
+func TestRun_EnvReplacement_NotSet(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "")
+	output := filepath.Join(d, "output.txt")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	os.Unsetenv("RCF_TEST_NOT_SET")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "${env:RCF_TEST_NOT_SET}",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: environment variable \"RCF_TEST_NOT_SET\" is not set\n", buf.String())
+}
+
+func TestRun_Overwrite(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "aaa")
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "x",
+		"--overwrite",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, input)
+	assert.Equal(t, "xxx", replaced)
+}
+
+func TestRun_Charset_UTF8(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "あいうえお")
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-r", "あ.う",
+		"-t", "",
+		"-c", "utf-8",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readString(t, output)
+	assert.Equal(t, "えお", replaced)
+}
+
+func TestRun_Charset_SJIS(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.txt", stringToByte(t, "あいうえお", japanese.ShiftJIS))
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-r", "あ.う",
+		"-t", "",
+		"-c", "sjis",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := byteToString(t, readBytes(t, output), japanese.ShiftJIS)
+	assert.Equal(t, "えお", replaced)
+}
+
+func TestRun_Charset_Binary(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.txt", []byte{0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0xF0})
+	output := filepath.Join(d, "output.txt")
+
+	args := []string{
+		"-i", input,
+		"-s", "x00x01",
+		"-t", "",
+		"-c", "binary",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readBytes(t, output)
+	assert.Equal(t, []byte{0x00, 0x02, 0xF0}, replaced)
+}
+func TestRun_Charset_Hex(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{0x00, 0xAB, 0xCD, 0xEF})
+	output := filepath.Join(d, "output.bin")
+
+	args := []string{
+		"-i", input,
+		"-r", "^00",
+		"-t", "FF",
+		"-c", "hex",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readBytes(t, output)
+	assert.Equal(t, []byte{0xFF, 0xAB, 0xCD, 0xEF}, replaced)
+}
+
+func TestRun_Charset_Latin1(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{'a', 0x80, 0x9F, 0xFF, 0x00, 0x00})
+	output := filepath.Join(d, "output.bin")
+
+	args := []string{
+		"-i", input,
+		"-r", `\x{00}+$`,
+		"-t", "",
+		"-c", "latin1",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readBytes(t, output)
+	assert.Equal(t, []byte{'a', 0x80, 0x9F, 0xFF}, replaced)
+}
+
+func TestRun_Charset_Latin1_String(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{'a', 0xE9, 0x80, 0xE9})
+	output := filepath.Join(d, "output.bin")
+
+	args := []string{
+		"-i", input,
+		"-s", "é",
+		"-t", "e",
+		"-c", "latin1",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readBytes(t, output)
+	assert.Equal(t, []byte{'a', 'e', 0x80, 'e'}, replaced)
+}
+
+func TestRun_Charset_Binary_Limit(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{0x00, 0x01, 0x00, 0x01})
+	output := filepath.Join(d, "output.bin")
+
+	args := []string{
+		"-i", input,
+		"-s", "x00x01",
+		"-t", "xFF",
+		"-c", "binary",
+		"--max-count", "1",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readBytes(t, output)
+	assert.Equal(t, []byte{0xFF, 0x00, 0x01}, replaced)
+}
+
+func TestRun_BinaryPattern(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{0x04, 0xF4, 'P', 'K', 0x03, 0x04, 0x14, 0x00, 'P', 'K', 0x01, 0x02})
+	output := filepath.Join(d, "output.bin")
+
+	args := []string{
+		"-i", input,
+		"--binary-pattern", `"PK" 03 04 ??`,
+		"-t", "50 4B 05 06 ??",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readBytes(t, output)
+	assert.Equal(t, []byte{0x04, 0xF4, 'P', 'K', 0x05, 0x06, 0x14, 0x00, 'P', 'K', 0x01, 0x02}, replaced)
+}
+
+func TestRun_BinaryPattern_Invalid(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{})
+	output := filepath.Join(d, "output.bin")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"--binary-pattern", "0",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: invalid binary pattern \"0\": hex must be 2 digits\n", buf.String())
+}
+
+func TestRun_Patch(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{0x00, 0x74, 0x05, 0x00, 0x00})
+	output := filepath.Join(d, "output.bin")
+
+	args := []string{
+		"-i", input,
+		"--at", "0x01",
+		"--expect", "74 05",
+		"--bytes", "EB 05",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readBytes(t, output)
+	assert.Equal(t, []byte{0x00, 0xEB, 0x05, 0x00, 0x00}, replaced)
+}
+
+func TestRun_PatchFile(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{0x00, 0x74, 0x05, 'N', 'G'})
+	patchFile := createFileWriteString(t, d, "patch.txt", "# offset expected new\n0x01 7405 EB05\n3 \"NG\" \"OK\"\n")
+	output := filepath.Join(d, "output.bin")
+
+	args := []string{
+		"-i", input,
+		"--patch-file", patchFile,
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readBytes(t, output)
+	assert.Equal(t, []byte{0x00, 0xEB, 0x05, 'O', 'K'}, replaced)
+}
+
+func TestRun_Patch_Mismatch(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{0x00, 0x75, 0x05})
+	output := filepath.Join(d, "output.bin")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"--at", "1",
+		"--expect", "74 05",
+		"--bytes", "EB 05",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: "+input+": offset 0x1: expected 74 05 but found 75 05\n", buf.String())
+
+	// 書き込まれないこと
+	assert.NoFileExists(t, output)
+}
+
+func TestRun_PreserveLength(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{0x00, 0x01, 0x02, 0x01, 0x02})
+	output := filepath.Join(d, "output.bin")
+
+	args := []string{
+		"-i", input,
+		"-s", "x01x02",
+		"-t", "x03x04",
+		"-c", "binary",
+		"--preserve-length",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readBytes(t, output)
+	assert.Equal(t, []byte{0x00, 0x03, 0x04, 0x03, 0x04}, replaced)
+}
+
+func TestRun_PreserveLength_Pad(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{'/', 'u', 's', 'r', '/', 'l', 'i', 'b', 0x00, 0xFF})
+	output := filepath.Join(d, "output.bin")
+
+	args := []string{
+		"-i", input,
+		"--binary-pattern", `"/usr/lib"`,
+		"-t", `"/lib"`,
+		"--preserve-length",
+		"--pad", "00",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+	replaced := readBytes(t, output)
+	assert.Equal(t, []byte{'/', 'l', 'i', 'b', 0x00, 0x00, 0x00, 0x00, 0x00, 0xFF}, replaced)
+}
+
+func TestRun_PreserveLength_Violation(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{0x00, 0x01, 0x02, 0x01, 0x02})
+	output := filepath.Join(d, "output.bin")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "x01x02",
+		"-t", "x03",
+		"-c", "binary",
+		"--preserve-length",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t,
+		input+": offset 0x1: 2 bytes -> 1 bytes\n"+
+			input+": offset 0x3: 2 bytes -> 1 bytes\n"+
+			"\nError: "+input+": 2 replacements would change the length\n",
+		buf.String())
+
+	// 書き込まれないこと
+	assert.NoFileExists(t, output)
+}
+
+func TestRun_PreserveLength_Violation_Lines(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "あいう abc\nx abc\n")
+	output := filepath.Join(d, "output.txt")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "abc",
+		"-t", "ab",
+		"--lines", "2:",
+		"--line-mode",
+		"--preserve-length",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	// 範囲の中ではなく、ファイルでの位置
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t,
+		input+": offset 0x10: 3 bytes -> 2 bytes\n"+
+			"\nError: "+input+": 1 replacements would change the length\n",
+		buf.String())
+
+	assert.NoFileExists(t, output)
+}
+
+func TestRun_PreserveLength_Operation(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "abc\nx\n")
+	output := filepath.Join(d, "output.txt")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "abc",
+		"-p", "delete-line",
+		"--preserve-length",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: --preserve-length can only be used with --operation replace\n", buf.String())
+
+	assert.NoFileExists(t, output)
+}
+
+func TestRun_Pad_WithoutPreserveLength(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{0x00})
+	output := filepath.Join(d, "output.bin")
+
+	args := []string{
+		"-i", input,
+		"-s", "x00",
+		"-t", "",
+		"-c", "binary",
+		"--pad", "00",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+}
+
+func TestRun_HexDump(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	inputBytes := []byte{'a', 'b', 'c', 0x00, 0x01, 'P', 'K', 0x03, 0x04, 0x14, 0x00, ' ', 't', 'a', 'i', 'l'}
+	input := createFileWriteBytes(t, d, "input.bin", inputBytes)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	args := []string{
+		"-i", input,
+		"-s", "x03x04",
+		"-t", "x05x06x07",
+		"-c", "binary",
+		"--hex-dump",
+		"--dump-context", "4",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t,
+		input+": offset 0x7: 2 bytes -> 3 bytes\n"+
+			"- 00000003: 0001 504b 0304 1400 2074                 ..PK.... t\n"+
+			"+ 00000003: 0001 504b 0506 0714 0020 74              ..PK..... t\n",
+		buf.String())
+
+	// 書き込まれないこと
+	assert.Equal(t, inputBytes, readBytes(t, input))
+}
+
+func TestRun_HexDump_Dir(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	inputDir := filepath.Join(d, "input")
+	require.NoError(t, os.Mkdir(inputDir, 0777))
+	input1 := createFileWriteBytes(t, inputDir, "1.bin", []byte("0123456789abcdefghij"))
+	createFileWriteBytes(t, inputDir, "2.bin", []byte("xyz"))
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	args := []string{
+		"-i", inputDir,
+		"--binary-pattern", `"a" ?? "c"`,
+		"-t", `"A" ?? "C"`,
+		"--hex-dump",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t,
+		input1+": offset 0xA: 3 bytes -> 3 bytes\n"+
+			"- 00000000: 3031 3233 3435 3637 3839 6162 6364 6566  0123456789abcdef\n"+
+			"- 00000010: 6768 696a                                ghij\n"+
+			"+ 00000000: 3031 3233 3435 3637 3839 4162 4364 6566  0123456789AbCdef\n"+
+			"+ 00000010: 6768 696a                                ghij\n",
+		buf.String())
+}
+
+func TestRun_HexDump_InvalidCombination(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{0x00})
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "x00",
+		"-c", "binary",
+		"--lines", "1:2",
+		"--hex-dump",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: --hex-dump cannot be used with line, scope, format, patch, length and rename options\n", buf.String())
+}
+
+func TestRun_Archive(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	var zipBuf bytes.Buffer
+	zipWriter := zip.NewWriter(&zipBuf)
+	for _, entry := range []struct{ name, content string }{
+		{"conf/app.properties", "url=http://example.com\n"},
+		{"image.bin", "\x00\x01"},
+	} {
+		w, err := zipWriter.Create(entry.name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+
+	input := createFileWriteBytes(t, d, "bundle.zip", zipBuf.Bytes())
+	output := filepath.Join(d, "output.zip")
+
+	args := []string{
+		"-i", input,
+		"-s", "http://",
+		"-t", "https://",
+		"--archive",
+		"-R",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+
+	reader, err := zip.OpenReader(output)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	require.Len(t, reader.File, 2)
+	assert.Equal(t, "conf/app.properties", reader.File[0].Name)
+	assert.Equal(t, "url=https://example.com\n", readZipEntry(t, reader.File[0]))
+	assert.Equal(t, "image.bin", reader.File[1].Name)
+	assert.Equal(t, "\x00\x01", readZipEntry(t, reader.File[1]))
+}
+
+func TestRun_Archive_InvalidCombination(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.zip", []byte{})
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "b",
+		"--archive",
+		"--rename-paths",
+		"-O",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: --archive cannot be used with rename and mirror options\n", buf.String())
+}
+
+func TestRun_Gzip(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	var gzipBuf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipBuf)
+	_, err := gzipWriter.Write([]byte("host=192.168.0.1\n"))
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+
+	input := createFileWriteBytes(t, d, "app.log.gz", gzipBuf.Bytes())
+
+	args := []string{
+		"-i", input,
+		"-s", "192.168.0.1",
+		"-t", "x.x.x.x",
+		"-O",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+
+	gzipReader, err := gzip.NewReader(bytes.NewReader(readBytes(t, input)))
+	require.NoError(t, err)
+	result, err := io.ReadAll(gzipReader)
+	require.NoError(t, err)
+	assert.Equal(t, "host=x.x.x.x\n", string(result))
+}
+
+func TestRun_Config(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	createFileWriteString(t, d, ".rcf.yaml",
+		"string: abc\n"+
+			"replacement: xyz\n"+
+			"presets:\n"+
+			"  upper:\n"+
+			"    replacement: XYZ\n")
+	workDir := createDir(t, d, "work")
+	defer setConfigDir(workDir)()
+
+	input1 := createFileWriteString(t, workDir, "1.txt", "abc")
+	input2 := createFileWriteString(t, workDir, "2.txt", "abc")
+	input3 := createFileWriteString(t, workDir, "3.txt", "abc")
+
+	// ACT
+	c1 := run([]string{"-i", input1, "-O"})
+	c2 := run([]string{"-i", input2, "-O", "--preset", "upper"})
+	c3 := run([]string{"-i", input3, "-O", "--preset", "upper", "-t", "123"})
+
+	// ASSERT
+	require.Equal(t, OK, c1)
+	require.Equal(t, OK, c2)
+	require.Equal(t, OK, c3)
+
+	// 設定ファイル < プリセット < コマンドライン
+	assert.Equal(t, "xyz", readString(t, input1))
+	assert.Equal(t, "XYZ", readString(t, input2))
+	assert.Equal(t, "123", readString(t, input3))
+}
+
+func TestRun_Config_UnknownPreset(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	config := createFileWriteString(t, d, ".rcf.yaml", "charset: sjis\n")
+	defer setConfigDir(d)()
+
+	input := createFileWriteString(t, d, "input.txt", "abc")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "b",
+		"-O",
+		"--preset", "jp-legacy",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: preset \"jp-legacy\" is not defined in "+config+"\n", buf.String())
+}
+
+func TestRun_ConfigShow(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	config := createFileWriteString(t, d, ".rcf.yaml",
+		"charset: sjis\n"+
+			"recursive: true\n"+
+			"presets:\n"+
+			"  jp-legacy:\n"+
+			"    charset: euc-jp\n"+
+			"    column: [name, address]\n")
+	defer setConfigDir(d)()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	args := []string{
+		"config", "show",
+		"--preset", "jp-legacy",
+		"-s", "abc",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	// デフォルトの値も含めて出力 (デフォルトが空のものは省略)
+	assert.Equal(t,
+		"# "+config+"\n"+
+			"string: abc\n"+
+			"preserve-length: false\n"+
+			"hex-dump: false\n"+
+			"dump-context: 16\n"+
+			"operation: replace\n"+
+			"escape: false\n"+
+			"multiline: false\n"+
+			"dotall: false\n"+
+			"line-mode: false\n"+
+			"inclusive: false\n"+
+			"go-rename: false\n"+
+			"occurrence: 0\n"+
+			"max-count: 0\n"+
+			"max-total: 0\n"+
+			"check-idempotent: false\n"+
+			"path: $..*\n"+
+			"csv: false\n"+
+			"column:\n"+
+			"- name\n"+
+			"- address\n"+
+			"csv-delimiter: ','\n"+
+			"csv-quote: '\"'\n"+
+			"no-header: false\n"+
+			"rename-paths: false\n"+
+			"paths-only: false\n"+
+			"dry-run: false\n"+
+			"recursive: true\n"+
+			"mirror: false\n"+
+			"archive: false\n"+
+			"charset: euc-jp\n"+
+			"compress: auto\n"+
+			"overwrite: false\n",
+		buf.String())
+}
+
+func TestRun_ConfigShow_NotFound(t *testing.T) {
+
+	// ARRANGE
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	// ACT
+	c := run([]string{"config", "show"})
+
+	// ASSERT
+	require.Equal(t, OK, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.True(t, strings.HasPrefix(buf.String(), "# .rcf.yaml not found\n"))
+	assert.Contains(t, buf.String(), "\ncharset: UTF-8\n")
+	assert.NotContains(t, buf.String(), "input:")
+}
+
+func TestRun_Charset_Invalid(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "")
+	output := filepath.Join(d, "output.txt")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-r", "x",
+		"-t", "",
+		"-c", "xxxx", // 存在しないCharset
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: htmlindex: invalid encoding name\n", buf.String())
+}
+
+func TestRun_InvalidRegex(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "")
+	output := filepath.Join(d, "output.txt")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-r", "[a", // 不正な正規表現
+		"-t", "",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: error parsing regexp: missing closing ]: `[a`\n", buf.String())
+}
+
+func TestRun_InvalidEscape_Regex(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "")
+	output := filepath.Join(d, "output.txt")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-r", `\x`, // 不正なエスケープ
+		"-t", "",
+		"-e",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: --regex is invalid string: \\x\n", buf.String())
+}
+
+func TestRun_InvalidEscape_String(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "")
+	output := filepath.Join(d, "output.txt")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", `\x`, // 不正なエスケープ
+		"-t", "",
+		"-e",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: --string is invalid string: \\x\n", buf.String())
+}
+
+func TestRun_InvalidEscape_Replacement(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "")
+	output := filepath.Join(d, "output.txt")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", `\`, // 不正なエスケープ
+		"-e",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: --replacement is invalid string: \\\n", buf.String())
+}
+
+func TestRun_InputNotFound(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := filepath.Join(d, "input") // 存在しない
+	output := filepath.Join(d, "output")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Contains(t, buf.String(), "Error:") // 実行環境によってファイルが存在しない場合のエラーメッセージが異なるので、Errorだけで判定
+}
+
+func TestRun_OutputNotFound(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createDir(t, d, "input")
+	output := filepath.Join(d, "a", "b") // 親ディレクトリ自体が無い
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", input,
+		"-s", "a",
+		"-t", "",
+		"-o", output,
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Contains(t, buf.String(), "Error:") // 実行環境によってファイルが存在しない場合のエラーメッセージが異なるので、Errorだけで判定
+}
+
+func TestRun_Help(t *testing.T) {
+
+	// ARRANGE
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	args := []string{
+		"-h",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, OK, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Contains(t, buf.String(), "Usage: rcf")
+}
+
+func TestRun_InvalidArgs_Empty(t *testing.T) {
+
+	// ARRANGE
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Contains(t, buf.String(), "Usage: rcf")
+}
+
+func TestRun_InvalidArgs_Unknown(t *testing.T) {
+
+	// ARRANGE
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-x",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Contains(t, buf.String(), "unknown shorthand flag: 'x' in -x")
+}
+
+func TestRun_InvalidArgs_InputEmpty(t *testing.T) {
+
+	// ARRANGE
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-s", "a",
+		"-t", "",
+		"-o", "out",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Contains(t, buf.String(), "Usage: rcf")
+}
+
+func TestRun_InvalidArgs_StringAndRegexEmpty(t *testing.T) {
+
+	// ARRANGE
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", "in",
+		"-t", "",
+		"-o", "out",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Contains(t, buf.String(), "Usage: rcf")
+}
+
+func TestRun_InvalidArgs_OutputAndOverwriteEmpty(t *testing.T) {
+
+	// ARRANGE
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", "in",
+		"-s", "x",
+		"-t", "",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Contains(t, buf.String(), "Usage: rcf")
+}
+
+func TestRun_InvalidArgs_BetweenEndEmpty(t *testing.T) {
+
+	// ARRANGE
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	args := []string{
+		"-i", "in",
+		"-s", "x",
+		"-t", "",
+		"-o", "out",
+		"--between-start", "BEGIN",
+	}
+
+	// ACT
+	c := run(args)
+
+	// ASSERT
+	require.Equal(t, NG, c)
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	assert.Equal(t, "\nError: --between-start and --between-end must be specified together\n", buf.String())
+}
+
+//////////////////////////////////////////////////////////
+
+func createFileWriteBytes(t *testing.T, dir string, name string, content []byte) string {
+
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = file.Write(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return file.Name()
+}
+
+func createFileWriteString(t *testing.T, dir string, name string, content string) string {
+
+	return createFileWriteBytes(t, dir, name, []byte(content))
+}
+
+func createTempDir(t *testing.T) string {
+
+	tempDir, err := os.MkdirTemp("", "rcf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tempDir
+}
+
+func createDir(t *testing.T, parent string, name string) string {
+
+	dir := filepath.Join(parent, name)
+	err := os.Mkdir(dir, os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func readBytes(t *testing.T, name string) []byte {
+
+	bo, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bo
+}
+
+func readString(t *testing.T, name string) string {
+
+	bo := readBytes(t, name)
+	return string(bo)
+}
+
+func byteToString(t *testing.T, bytes []byte, enc encoding.Encoding) string {
+	decoded, err := enc.NewDecoder().Bytes(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(decoded)
+}
+
+func stringToByte(t *testing.T, str string, enc encoding.Encoding) []byte {
+	encoded, err := enc.NewEncoder().Bytes([]byte(str))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return encoded
+}
+
+func readZipEntry(t *testing.T, file *zip.File) string {
+
+	rc, err := file.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+// 設定ファイルを探すディレクトリを変更して、元に戻す関数を返す
+func setConfigDir(dir string) func() {
+
+	configDir = dir
+
+	return func() {
+		configDir = ""
+	}
+}
@@ -0,0 +1,350 @@
+package rcf
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	archiveZip   = "zip"
+	archiveTar   = "tar"
+	archiveTarGz = "tar.gz"
+)
+
+// アーカイブ内のエントリをディレクトリと同じように置換して、同じ形式のアーカイブとして出力
+func replaceArchive(ctx context.Context, inputPath string, outputPath string, options Options) error {
+
+	archive, err := readArchive(inputPath)
+	if err != nil {
+		return err
+	}
+
+	// 展開したものを上書きで置換 (対象外のエントリはそのまま残る)
+	if err := replaceFS(ctx, archive.fs, ".", archive.fs, ".", true, options); err != nil {
+		return err
+	}
+
+	if options.HexDump {
+		return nil
+	}
+
+	// 全て置換してから書き込むので、上書きでも問題ない
+	var buf bytes.Buffer
+	if err := archive.write(&buf); err != nil {
+		return err
+	}
+
+	return os.WriteFile(outputPath, buf.Bytes(), 0666)
+}
+
+type archive struct {
+	path       string
+	format     string
+	fs         *archiveFS
+	original   map[string][]byte // 変更されたかどうかの判定用
+	zipReader  *zip.Reader
+	zipComment string
+	tarHeaders []*tar.Header
+	gzipHeader gzip.Header
+}
+
+// 展開したエントリ (表示するパスはアーカイブ内のパス)
+type archiveFS struct {
+	*MemFS
+	path string
+}
+
+func (a *archiveFS) displayPath(name string) string {
+
+	return filepath.Join(a.path, filepath.FromSlash(name))
+}
+
+func readArchive(archivePath string) (*archive, error) {
+
+	contents, err := os.ReadFile(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &archive{
+		path:     archivePath,
+		fs:       &archiveFS{MemFS: NewMemFS(), path: archivePath},
+		original: map[string][]byte{},
+	}
+
+	// 形式はマジックナンバーで判定
+	switch {
+	case bytes.HasPrefix(contents, []byte("PK\x03\x04")) || bytes.HasPrefix(contents, []byte("PK\x05\x06")):
+		a.format = archiveZip
+		err = a.readZip(contents)
+	case bytes.HasPrefix(contents, []byte{0x1F, 0x8B}):
+		a.format = archiveTarGz
+		err = a.readTarGz(contents)
+	case isTar(contents):
+		a.format = archiveTar
+		err = a.readTar(bytes.NewReader(contents))
+	default:
+		return nil, fmt.Errorf("%s: unknown archive format (zip, tar and tar.gz are supported)", archivePath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", archivePath, err)
+	}
+
+	return a, nil
+}
+
+func (a *archive) readZip(contents []byte) error {
+
+	reader, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+	if err != nil {
+		return err
+	}
+	a.zipReader = reader
+	a.zipComment = reader.Comment
+
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			if err := a.addDir(file.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		if !file.Mode().IsRegular() {
+			// シンボリックリンクなどは置換対象外
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+
+		if err := a.addFile(file.Name, data, file.Mode()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *archive) readTarGz(contents []byte) error {
+
+	reader, err := gzip.NewReader(bytes.NewReader(contents))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	a.gzipHeader = reader.Header
+
+	return a.readTar(reader)
+}
+
+func (a *archive) readTar(r io.Reader) error {
+
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		a.tarHeaders = append(a.tarHeaders, header)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := a.addDir(header.Name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				return err
+			}
+			if err := a.addFile(header.Name, data, header.FileInfo().Mode()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (a *archive) addDir(entryName string) error {
+
+	name, err := archiveEntryName(entryName)
+	if err != nil {
+		return err
+	}
+
+	return a.mkdirAll(name)
+}
+
+func (a *archive) addFile(entryName string, data []byte, mode fs.FileMode) error {
+
+	name, err := archiveEntryName(entryName)
+	if err != nil {
+		return err
+	}
+
+	// ディレクトリのエントリが無い場合もある
+	if err := a.mkdirAll(path.Dir(name)); err != nil {
+		return err
+	}
+
+	a.original[name] = data
+	return a.fs.WriteFile(name, data, mode.Perm())
+}
+
+func (a *archive) mkdirAll(name string) error {
+
+	if name == "." {
+		return nil
+	}
+	if _, err := a.fs.Lstat(name); err == nil {
+		return nil
+	}
+
+	if err := a.mkdirAll(path.Dir(name)); err != nil {
+		return err
+	}
+
+	return a.fs.Mkdir(name, fs.ModePerm)
+}
+
+func archiveEntryName(entryName string) (string, error) {
+
+	name := strings.TrimSuffix(strings.TrimPrefix(entryName, "./"), "/")
+	if !fs.ValidPath(name) || name == "." {
+		return "", fmt.Errorf("invalid entry name \"%s\"", entryName)
+	}
+
+	return name, nil
+}
+
+// 元のアーカイブと同じ順番、同じメタデータで出力
+func (a *archive) write(w io.Writer) error {
+
+	switch a.format {
+	case archiveZip:
+		return a.writeZip(w)
+	case archiveTarGz:
+		gzipWriter := gzip.NewWriter(w)
+		gzipWriter.Header = a.gzipHeader
+		if err := a.writeTar(gzipWriter); err != nil {
+			return err
+		}
+		return gzipWriter.Close()
+	default:
+		return a.writeTar(w)
+	}
+}
+
+func (a *archive) writeZip(w io.Writer) error {
+
+	buffered := bufio.NewWriter(w)
+	writer := zip.NewWriter(buffered)
+
+	for _, file := range a.zipReader.File {
+		data, changed, err := a.changedData(file.Name)
+		if err != nil {
+			return err
+		}
+
+		if !changed {
+			// 変更の無いエントリは圧縮されたまま、そのままコピー
+			if err := writer.Copy(file); err != nil {
+				return err
+			}
+			continue
+		}
+
+		header := file.FileHeader
+		entryWriter, err := writer.CreateHeader(&header)
+		if err != nil {
+			return err
+		}
+		if _, err := entryWriter.Write(data); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.SetComment(a.zipComment); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return buffered.Flush()
+}
+
+func (a *archive) writeTar(w io.Writer) error {
+
+	writer := tar.NewWriter(w)
+
+	for _, original := range a.tarHeaders {
+		header := *original
+
+		var data []byte
+		if header.Typeflag == tar.TypeReg {
+			var err error
+			data, _, err = a.changedData(header.Name)
+			if err != nil {
+				return err
+			}
+			header.Size = int64(len(data))
+		}
+
+		if err := writer.WriteHeader(&header); err != nil {
+			return err
+		}
+		if _, err := writer.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// 置換後の内容と、変更されたかどうか
+func (a *archive) changedData(entryName string) ([]byte, bool, error) {
+
+	name, err := archiveEntryName(entryName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	original, ok := a.original[name]
+	if !ok {
+		// 展開していないもの (ディレクトリやシンボリックリンク)
+		return nil, false, nil
+	}
+
+	data, err := a.fs.ReadFile(name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return data, !bytes.Equal(original, data), nil
+}
+
+func isTar(b []byte) bool {
+
+	return len(b) > 262 && string(b[257:262]) == "ustar"
+}
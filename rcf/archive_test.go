@@ -0,0 +1,272 @@
+package rcf
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_ArchiveZip(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	modified := time.Date(2021, 5, 1, 10, 20, 30, 0, time.UTC)
+	input := createFileWriteBytes(t, d, "input.zip", createZip(t, []zipEntry{
+		{name: "z.txt", content: "http://example.com", mode: 0600, modified: modified},
+		{name: "dir/", mode: 0755 | os.ModeDir, modified: modified},
+		{name: "dir/a.txt", content: "url=http://example.com/a", mode: 0644, modified: modified},
+		{name: "b.txt", content: "none", mode: 0644, modified: modified},
+	}))
+	output := filepath.Join(d, "output.zip")
+
+	events := []FileEvent{}
+
+	// ACT
+	err := Run(context.Background(), input, output, Options{
+		TargetString: "http://example.com",
+		Replacement:  "https://example.org",
+		Recursive:    true,
+		Archive:      true,
+		OnFile: func(event FileEvent) {
+			events = append(events, event)
+		},
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	// 順番とメタデータはそのまま
+	entries := readZip(t, output)
+	assert.Equal(t, []zipEntry{
+		{name: "z.txt", content: "https://example.org", mode: 0600, modified: modified},
+		{name: "dir/", mode: 0755 | os.ModeDir, modified: modified},
+		{name: "dir/a.txt", content: "url=https://example.org/a", mode: 0644, modified: modified},
+		{name: "b.txt", content: "none", mode: 0644, modified: modified},
+	}, entries)
+
+	assert.Equal(t, []FileEvent{
+		{InputPath: filepath.Join(input, "b.txt"), OutputPath: filepath.Join(input, "b.txt"), Action: FileReplaced},
+		{InputPath: filepath.Join(input, "dir", "a.txt"), OutputPath: filepath.Join(input, "dir", "a.txt"), Action: FileReplaced},
+		{InputPath: filepath.Join(input, "z.txt"), OutputPath: filepath.Join(input, "z.txt"), Action: FileReplaced},
+	}, events)
+}
+
+func TestRun_ArchiveTarGz(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	modified := time.Date(2021, 5, 1, 10, 20, 30, 0, time.UTC)
+	input := createFileWriteBytes(t, d, "input.tar.gz", createTarGz(t, []tarEntry{
+		{header: tar.Header{Name: "conf/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: modified}},
+		{header: tar.Header{Name: "conf/app.conf", Typeflag: tar.TypeReg, Mode: 0640, ModTime: modified}, content: "host=old.example.com\n"},
+		{header: tar.Header{Name: "conf/link", Typeflag: tar.TypeSymlink, Linkname: "app.conf", Mode: 0777, ModTime: modified}},
+		{header: tar.Header{Name: "README", Typeflag: tar.TypeReg, Mode: 0644, ModTime: modified}, content: "readme\n"},
+	}))
+
+	// ACT (上書き)
+	err := Run(context.Background(), input, input, Options{
+		TargetString: "old.example.com",
+		Replacement:  "new.example.com",
+		Recursive:    true,
+		Archive:      true,
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	entries := readTarGz(t, input)
+	require.Len(t, entries, 4)
+
+	assert.Equal(t, "conf/", entries[0].header.Name)
+	assert.Equal(t, byte(tar.TypeDir), entries[0].header.Typeflag)
+
+	assert.Equal(t, "conf/app.conf", entries[1].header.Name)
+	assert.Equal(t, "host=new.example.com\n", entries[1].content)
+	assert.Equal(t, int64(0640), entries[1].header.Mode)
+	assert.True(t, modified.Equal(entries[1].header.ModTime))
+
+	assert.Equal(t, "conf/link", entries[2].header.Name)
+	assert.Equal(t, byte(tar.TypeSymlink), entries[2].header.Typeflag)
+	assert.Equal(t, "app.conf", entries[2].header.Linkname)
+
+	assert.Equal(t, "README", entries[3].header.Name)
+	assert.Equal(t, "readme\n", entries[3].content)
+}
+
+func TestRun_ArchiveTar_NotRecursive(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	var buf bytes.Buffer
+	writeTar(t, &buf, []tarEntry{
+		{header: tar.Header{Name: "a.txt", Typeflag: tar.TypeReg, Mode: 0644}, content: "a"},
+		{header: tar.Header{Name: "sub/a.txt", Typeflag: tar.TypeReg, Mode: 0644}, content: "a"},
+	})
+	input := createFileWriteBytes(t, d, "input.tar", buf.Bytes())
+	output := filepath.Join(d, "output.tar")
+
+	// ACT
+	err := Run(context.Background(), input, output, Options{
+		TargetString: "a",
+		Replacement:  "A",
+		Archive:      true,
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	// ディレクトリと同じく、再帰指定が無い場合は直下のみ
+	f, err := os.Open(output)
+	require.NoError(t, err)
+	defer f.Close()
+
+	entries := readTar(t, f)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "A", entries[0].content)
+	assert.Equal(t, "a", entries[1].content)
+}
+
+func TestRun_ArchiveUnknownFormat(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "abc")
+
+	// ACT
+	err := Run(context.Background(), input, filepath.Join(d, "output.txt"), Options{
+		TargetString: "a",
+		Replacement:  "A",
+		Archive:      true,
+	})
+
+	// ASSERT
+	require.EqualError(t, err, input+": unknown archive format (zip, tar and tar.gz are supported)")
+}
+
+type zipEntry struct {
+	name     string
+	content  string
+	mode     os.FileMode
+	modified time.Time
+}
+
+func createZip(t *testing.T, entries []zipEntry) []byte {
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate, Modified: entry.modified}
+		header.SetMode(entry.mode)
+
+		w, err := writer.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func readZip(t *testing.T, name string) []zipEntry {
+
+	reader, err := zip.OpenReader(name)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	entries := []zipEntry{}
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+
+		entries = append(entries, zipEntry{
+			name:     file.Name,
+			content:  string(content),
+			mode:     file.Mode(),
+			modified: file.Modified.UTC(),
+		})
+	}
+
+	return entries
+}
+
+type tarEntry struct {
+	header  tar.Header
+	content string
+}
+
+func createTarGz(t *testing.T, entries []tarEntry) []byte {
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	writeTar(t, gzipWriter, entries)
+	require.NoError(t, gzipWriter.Close())
+
+	return buf.Bytes()
+}
+
+func writeTar(t *testing.T, w io.Writer, entries []tarEntry) {
+
+	writer := tar.NewWriter(w)
+	for _, entry := range entries {
+		header := entry.header
+		header.Size = int64(len(entry.content))
+		require.NoError(t, writer.WriteHeader(&header))
+		_, err := writer.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, writer.Close())
+}
+
+func readTarGz(t *testing.T, name string) []tarEntry {
+
+	f, err := os.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gzipReader, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	defer gzipReader.Close()
+
+	return readTar(t, gzipReader)
+}
+
+func readTar(t *testing.T, r io.Reader) []tarEntry {
+
+	reader := tar.NewReader(r)
+	entries := []tarEntry{}
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return entries
+		}
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		entries = append(entries, tarEntry{header: *header, content: string(content)})
+	}
+}
@@ -0,0 +1,413 @@
+package rcf
+
+import (
+	"sort"
+)
+
+// bzip2形式での圧縮 (標準ライブラリのcompress/bzip2は展開のみのため)
+
+const (
+	bzip2BlockMagic = 0x314159265359
+	bzip2EndMagic   = 0x177245385090
+	bzip2MaxCodeLen = 17
+	bzip2GroupSize  = 50
+)
+
+var bzip2CRCTable = func() [256]uint32 {
+
+	var table [256]uint32
+	for i := range table {
+		c := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if c&0x80000000 != 0 {
+				c = c<<1 ^ 0x04C11DB7
+			} else {
+				c <<= 1
+			}
+		}
+		table[i] = c
+	}
+	return table
+}()
+
+// 上位ビットから順に書き込む
+type bitWriter struct {
+	buf []byte
+	acc uint64
+	n   uint
+}
+
+func (w *bitWriter) write(n uint, v uint64) {
+
+	w.acc = w.acc<<n | v&(1<<n-1)
+	w.n += n
+	for w.n >= 8 {
+		w.buf = append(w.buf, byte(w.acc>>(w.n-8)))
+		w.n -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+
+	if w.n > 0 {
+		w.buf = append(w.buf, byte(w.acc<<(8-w.n)))
+		w.n = 0
+	}
+	return w.buf
+}
+
+// levelはブロックサイズ (100k単位で1から9)
+func bzip2Compress(src []byte, level int) []byte {
+
+	w := &bitWriter{}
+	w.write(24, 'B'<<16|'Z'<<8|'h')
+	w.write(8, uint64('0'+level))
+
+	// 連続したバイトをまとめた後のサイズで、ブロックサイズに収める
+	maxBlock := level*100000 - 19
+
+	var combined uint32
+	for len(src) > 0 {
+		block, n := bzip2RunLength(src, maxBlock)
+
+		crc := bzip2CRC(src[:n])
+		combined = (combined<<1 | combined>>31) ^ crc
+
+		writeBzip2Block(w, block, crc)
+		src = src[n:]
+	}
+
+	w.write(48, bzip2EndMagic)
+	w.write(32, uint64(combined))
+
+	return w.bytes()
+}
+
+func bzip2CRC(b []byte) uint32 {
+
+	crc := uint32(0xFFFFFFFF)
+	for _, c := range b {
+		crc = crc<<8 ^ bzip2CRCTable[byte(crc>>24)^c]
+	}
+	return ^crc
+}
+
+// 4から255個連続するバイトは、4個と残りの個数で表す
+// ブロックに収まった分と、その元のバイト数を返す
+func bzip2RunLength(src []byte, max int) ([]byte, int) {
+
+	dst := []byte{}
+	i := 0
+	for i < len(src) && len(dst)+5 <= max {
+		b := src[i]
+		run := 1
+		for i+run < len(src) && src[i+run] == b && run < 255 {
+			run++
+		}
+
+		if run < 4 {
+			for j := 0; j < run; j++ {
+				dst = append(dst, b)
+			}
+		} else {
+			dst = append(dst, b, b, b, b, byte(run-4))
+		}
+		i += run
+	}
+
+	return dst, i
+}
+
+func writeBzip2Block(w *bitWriter, block []byte, crc uint32) {
+
+	last, origPtr := bzip2BlockSort(block)
+
+	var inUse [256]bool
+	for _, b := range block {
+		inUse[b] = true
+	}
+	var seq [256]byte
+	nInUse := 0
+	for b, used := range inUse {
+		if used {
+			seq[b] = byte(nInUse)
+			nInUse++
+		}
+	}
+
+	symbols := bzip2MoveToFront(last, seq, nInUse)
+	alphaSize := nInUse + 2
+
+	freq := make([]int, alphaSize)
+	for _, s := range symbols {
+		freq[s]++
+	}
+	lengths := huffmanCodeLengths(freq, bzip2MaxCodeLen)
+	codes := canonicalCodes(lengths)
+
+	w.write(48, bzip2BlockMagic)
+	w.write(32, uint64(crc))
+	w.write(1, 0) // randomised
+	w.write(24, uint64(origPtr))
+
+	var used uint64
+	for i := 0; i < 16; i++ {
+		for j := 0; j < 16; j++ {
+			if inUse[i*16+j] {
+				used |= 1 << (15 - i)
+				break
+			}
+		}
+	}
+	w.write(16, used)
+	for i := 0; i < 16; i++ {
+		if used&(1<<(15-i)) == 0 {
+			continue
+		}
+		var bits uint64
+		for j := 0; j < 16; j++ {
+			if inUse[i*16+j] {
+				bits |= 1 << (15 - j)
+			}
+		}
+		w.write(16, bits)
+	}
+
+	// テーブルは2つ以上必要だが、同じものを使う
+	const groups = 2
+	selectors := (len(symbols) + bzip2GroupSize - 1) / bzip2GroupSize
+	w.write(3, groups)
+	w.write(15, uint64(selectors))
+	for i := 0; i < selectors; i++ {
+		w.write(1, 0)
+	}
+
+	for g := 0; g < groups; g++ {
+		current := lengths[0]
+		w.write(5, uint64(current))
+		for _, length := range lengths {
+			for ; current < length; current++ {
+				w.write(2, 2)
+			}
+			for ; current > length; current-- {
+				w.write(2, 3)
+			}
+			w.write(1, 0)
+		}
+	}
+
+	for _, s := range symbols {
+		w.write(uint(lengths[s]), uint64(codes[s]))
+	}
+}
+
+// ブロックの全ての回転を並べ替えて、各回転の最後のバイトと元の位置を返す
+func bzip2BlockSort(block []byte) ([]byte, int) {
+
+	n := len(block)
+	sa := make([]int, n)
+	rank := make([]int, n)
+	tmp := make([]int, n)
+
+	// 先頭のバイトで並べ替え
+	var count [257]int
+	for _, b := range block {
+		count[int(b)+1]++
+	}
+	for i := 1; i < len(count); i++ {
+		count[i] += count[i-1]
+	}
+	for i, b := range block {
+		sa[count[b]] = i
+		count[b]++
+	}
+	for i := 1; i < n; i++ {
+		rank[sa[i]] = rank[sa[i-1]]
+		if block[sa[i]] != block[sa[i-1]] {
+			rank[sa[i]]++
+		}
+	}
+
+	// 先頭kバイトの順位から、2kバイトの順位を求める
+	starts := make([]int, n+1)
+	for k := 1; k < n && rank[sa[n-1]] != n-1; k *= 2 {
+		// saはk以降の順位で並んでいるので、先頭kバイトの順位で安定ソート
+		for i := range starts {
+			starts[i] = 0
+		}
+		for _, r := range rank {
+			starts[r+1]++
+		}
+		for i := 1; i < len(starts); i++ {
+			starts[i] += starts[i-1]
+		}
+		for i, s := range sa {
+			tmp[i] = (s - k + n) % n
+		}
+		for _, s := range tmp {
+			sa[starts[rank[s]]] = s
+			starts[rank[s]]++
+		}
+
+		tmp[sa[0]] = 0
+		for i := 1; i < n; i++ {
+			current, prev := sa[i], sa[i-1]
+			tmp[current] = tmp[prev]
+			if rank[current] != rank[prev] || rank[(current+k)%n] != rank[(prev+k)%n] {
+				tmp[current]++
+			}
+		}
+		rank, tmp = tmp, rank
+	}
+
+	last := make([]byte, n)
+	origPtr := 0
+	for i, s := range sa {
+		if s == 0 {
+			origPtr = i
+		}
+		last[i] = block[(s+n-1)%n]
+	}
+
+	return last, origPtr
+}
+
+// 直前に出現した順の位置に変換し、0の連続はRUNA(0), RUNB(1)で個数を表す
+// 末尾はEOB
+func bzip2MoveToFront(last []byte, seq [256]byte, nInUse int) []uint16 {
+
+	order := make([]byte, nInUse)
+	for i := range order {
+		order[i] = byte(i)
+	}
+
+	symbols := []uint16{}
+	zeros := 0
+	flushZeros := func() {
+		if zeros == 0 {
+			return
+		}
+		zeros--
+		for {
+			symbols = append(symbols, uint16(zeros&1))
+			if zeros < 2 {
+				break
+			}
+			zeros = (zeros - 2) / 2
+		}
+		zeros = 0
+	}
+
+	for _, b := range last {
+		s := seq[b]
+		j := 0
+		for order[j] != s {
+			j++
+		}
+		copy(order[1:j+1], order[:j])
+		order[0] = s
+
+		if j == 0 {
+			zeros++
+			continue
+		}
+		flushZeros()
+		symbols = append(symbols, uint16(j+1))
+	}
+	flushZeros()
+
+	return append(symbols, uint16(nInUse+1))
+}
+
+// 全ての記号に符号を割り当て、最大の長さを超える場合は頻度を均して作り直す
+func huffmanCodeLengths(freq []int, maxLen int) []uint8 {
+
+	weights := make([]int, len(freq))
+	for i, f := range freq {
+		weights[i] = f + 1
+	}
+
+	for {
+		lengths := huffmanTreeDepths(weights)
+
+		ok := true
+		for _, l := range lengths {
+			if int(l) > maxLen {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return lengths
+		}
+
+		for i := range weights {
+			weights[i] = 1 + weights[i]/2
+		}
+	}
+}
+
+func huffmanTreeDepths(weights []int) []uint8 {
+
+	n := len(weights)
+	weight := make([]int, n, 2*n-1)
+	parent := make([]int, n, 2*n-1)
+	copy(weight, weights)
+
+	leaves := make([]int, n)
+	for i := range leaves {
+		leaves[i] = i
+	}
+	sort.SliceStable(leaves, func(i, j int) bool { return weight[leaves[i]] < weight[leaves[j]] })
+
+	// 葉と内部ノードは、それぞれ重みの小さい順に取り出せる
+	nodes := []int{}
+	pop := func() int {
+		if len(leaves) != 0 && (len(nodes) == 0 || weight[leaves[0]] <= weight[nodes[0]]) {
+			i := leaves[0]
+			leaves = leaves[1:]
+			return i
+		}
+		i := nodes[0]
+		nodes = nodes[1:]
+		return i
+	}
+
+	for i := 0; i < n-1; i++ {
+		a := pop()
+		b := pop()
+		node := len(weight)
+		weight = append(weight, weight[a]+weight[b])
+		parent = append(parent, -1)
+		parent[a] = node
+		parent[b] = node
+		nodes = append(nodes, node)
+	}
+
+	depths := make([]uint8, n)
+	for i := range depths {
+		for p := parent[i]; p != -1; p = parent[p] {
+			depths[i]++
+		}
+	}
+
+	return depths
+}
+
+// 長さの短い順、同じ長さは記号の順に符号を割り当てる
+func canonicalCodes(lengths []uint8) []uint32 {
+
+	codes := make([]uint32, len(lengths))
+	code := uint32(0)
+	for l := uint8(1); l <= bzip2MaxCodeLen; l++ {
+		for i, length := range lengths {
+			if length == l {
+				codes[i] = code
+				code++
+			}
+		}
+		code <<= 1
+	}
+
+	return codes
+}
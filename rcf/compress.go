@@ -0,0 +1,108 @@
+package rcf
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+const (
+	compressAuto  = "auto"
+	compressNone  = "none"
+	compressGzip  = "gzip"
+	compressBzip2 = "bzip2"
+	compressXz    = "xz"
+	compressZstd  = "zstd"
+)
+
+// 圧縮形式の判定に使うマジックナンバー
+var compressMagics = []struct {
+	format string
+	magic  []byte
+}{
+	{compressGzip, []byte{0x1F, 0x8B}},
+	{compressBzip2, []byte("BZh")},
+	// 判定のみで、展開はできない
+	{compressXz, []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}},
+	{compressZstd, []byte{0x28, 0xB5, 0x2F, 0xFD}},
+}
+
+func validateCompress(format string) error {
+
+	switch format {
+	case compressAuto, compressNone, compressGzip, compressBzip2:
+		return nil
+	case compressXz, compressZstd:
+		return fmt.Errorf("%s is not supported", format)
+	default:
+		return fmt.Errorf("unknown compression format: %s", format)
+	}
+}
+
+func detectCompression(b []byte) string {
+
+	for _, m := range compressMagics {
+		if bytes.HasPrefix(b, m.magic) {
+			return m.format
+		}
+	}
+
+	return compressNone
+}
+
+// 圧縮されたファイル
+// 展開した時の情報を、同じ形式で圧縮する時に使う
+// gzip, bzip2 のみ (xz, zstd は扱えない)
+type compressed struct {
+	format     string
+	gzipHeader gzip.Header
+	bzip2Level int
+}
+
+func (c *compressed) decompress(b []byte) ([]byte, error) {
+
+	switch c.format {
+	case compressGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		c.gzipHeader = reader.Header
+
+		return io.ReadAll(reader)
+	case compressBzip2:
+		// 圧縮し直す時も、同じブロックサイズにする
+		c.bzip2Level = 9
+		if len(b) > 3 && b[3] >= '1' && b[3] <= '9' {
+			c.bzip2Level = int(b[3] - '0')
+		}
+		return io.ReadAll(bzip2.NewReader(bytes.NewReader(b)))
+	default:
+		return nil, fmt.Errorf("%s is not supported", c.format)
+	}
+}
+
+func (c *compressed) compress(b []byte) ([]byte, error) {
+
+	switch c.format {
+	case compressGzip:
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		writer.Header = c.gzipHeader
+		if _, err := writer.Write(b); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	case compressBzip2:
+		return bzip2Compress(b, c.bzip2Level), nil
+	default:
+		return nil, fmt.Errorf("%s compression is not supported", c.format)
+	}
+}
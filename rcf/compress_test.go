@@ -0,0 +1,405 @@
+package rcf
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// "abc\n" を bzip2 で圧縮したもの
+var bzip2Abc = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xad, 0x67,
+	0x55, 0xd6, 0x00, 0x00, 0x00, 0xc1, 0x00, 0x00, 0x10, 0x38, 0x00, 0x20,
+	0x00, 0x21, 0x9a, 0x68, 0x33, 0x4d, 0x13, 0x3c, 0x5d, 0xc9, 0x14, 0xe1,
+	0x42, 0x42, 0xb5, 0x9d, 0x57, 0x58,
+}
+
+func TestDetectCompression(t *testing.T) {
+
+	assert.Equal(t, "gzip", detectCompression(createGzip(t, "abc", gzip.Header{})))
+	assert.Equal(t, "bzip2", detectCompression(bzip2Abc))
+	assert.Equal(t, "xz", detectCompression([]byte{0xFD, '7', 'z', 'X', 'Z', 0x00, 0x00}))
+	assert.Equal(t, "zstd", detectCompression([]byte{0x28, 0xB5, 0x2F, 0xFD, 0x00}))
+	assert.Equal(t, "none", detectCompression([]byte("abc")))
+	assert.Equal(t, "none", detectCompression([]byte{}))
+}
+
+func TestCompressed_Gzip(t *testing.T) {
+
+	// ARRANGE
+	modTime := time.Date(2021, 5, 1, 10, 20, 30, 0, time.UTC)
+	c := &compressed{format: "gzip"}
+
+	// ACT
+	contents, err := c.decompress(createGzip(t, "abc", gzip.Header{Name: "a.txt", ModTime: modTime}))
+	require.NoError(t, err)
+
+	result, err := c.compress([]byte("xyz"))
+	require.NoError(t, err)
+
+	// ASSERT
+	assert.Equal(t, "abc", string(contents))
+
+	// ヘッダは元のものを引き継ぐ
+	reader, err := gzip.NewReader(bytes.NewReader(result))
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt", reader.Header.Name)
+	assert.True(t, modTime.Equal(reader.Header.ModTime))
+
+	decompressed, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "xyz", string(decompressed))
+}
+
+func TestCompressed_Bzip2(t *testing.T) {
+
+	// ARRANGE
+	c := &compressed{format: "bzip2"}
+
+	// ACT
+	contents, err := c.decompress(bzip2Abc)
+	require.NoError(t, err)
+
+	result, err := c.compress([]byte("xyz"))
+	require.NoError(t, err)
+
+	// ASSERT
+	assert.Equal(t, "abc\n", string(contents))
+
+	// 元と同じブロックサイズ
+	assert.Equal(t, "BZh9", string(result[:4]))
+
+	decompressed, err := io.ReadAll(bzip2.NewReader(bytes.NewReader(result)))
+	require.NoError(t, err)
+	assert.Equal(t, "xyz", string(decompressed))
+}
+
+func TestBzip2Compress(t *testing.T) {
+
+	inputs := [][]byte{
+		{},
+		[]byte("a"),
+		[]byte(strings.Repeat("ab", 1000)),
+		// 255個を超える連続
+		[]byte(strings.Repeat("x", 1000) + "y" + strings.Repeat("x", 259)),
+		// 複数のブロック
+		[]byte(strings.Repeat("user=alice, id=12345\n", 10000)),
+	}
+	for b := 0; b < 512; b++ {
+		inputs[len(inputs)-1] = append(inputs[len(inputs)-1], byte(b*7))
+	}
+
+	for _, input := range inputs {
+		// ACT
+		result := bzip2Compress(input, 1)
+
+		// ASSERT
+		decompressed, err := io.ReadAll(bzip2.NewReader(bytes.NewReader(result)))
+		require.NoError(t, err)
+		assert.Equal(t, input, decompressed)
+	}
+}
+
+func TestRun_Gzip(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.log.gz", createGzip(t, "user=alice\n", gzip.Header{Name: "input.log"}))
+	output := filepath.Join(d, "output.log.gz")
+
+	// ACT
+	err := Run(context.Background(), input, output, Options{
+		TargetString: "alice",
+		Replacement:  "***",
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	reader, err := gzip.NewReader(bytes.NewReader(readBytes(t, output)))
+	require.NoError(t, err)
+	assert.Equal(t, "input.log", reader.Header.Name)
+
+	contents, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "user=***\n", string(contents))
+}
+
+func TestRun_Gzip_NotChanged(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	inputBytes := createGzip(t, "user=bob\n", gzip.Header{})
+	input := createFileWriteBytes(t, d, "input.log.gz", inputBytes)
+	output := filepath.Join(d, "output.log.gz")
+
+	// ACT
+	err := Run(context.Background(), input, output, Options{
+		TargetString: "alice",
+		Replacement:  "***",
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	// 圧縮し直さずにそのまま
+	assert.Equal(t, inputBytes, readBytes(t, output))
+}
+
+func TestRun_Gzip_CompressNone(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.gz", []byte{0x1F, 0x8B, 0x08})
+	output := filepath.Join(d, "output.gz")
+
+	// ACT
+	err := Run(context.Background(), input, output, Options{
+		BinaryPattern: "08",
+		Replacement:   "09",
+		Compress:      "none",
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x1F, 0x8B, 0x09}, readBytes(t, output))
+}
+
+func TestRun_Bzip2(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bz2", bzip2Abc)
+
+	// ACT
+	err := Run(context.Background(), input, filepath.Join(d, "output.bz2"), Options{
+		TargetString: "b",
+		Replacement:  "B",
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	contents, err := io.ReadAll(bzip2.NewReader(bytes.NewReader(readBytes(t, filepath.Join(d, "output.bz2")))))
+	require.NoError(t, err)
+	assert.Equal(t, "aBc\n", string(contents))
+}
+
+func TestRun_Xz(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	inputBytes := []byte{0xFD, '7', 'z', 'X', 'Z', 0x00, 0x00, 0x04, 'a', 'b', 'c'}
+	input := createFileWriteBytes(t, d, "input.xz", inputBytes)
+
+	// ACT
+	err := Run(context.Background(), input, input, Options{
+		TargetString: "b",
+		Replacement:  "B",
+	})
+
+	// ASSERT
+	fileErr := &FileError{}
+	require.ErrorAs(t, err, &fileErr)
+	assert.Equal(t, input, fileErr.Path)
+	assert.EqualError(t, err, input+": xz is not supported (use --compress none to process the bytes as they are)")
+
+	// 圧縮されたものを壊さない
+	assert.Equal(t, inputBytes, readBytes(t, input))
+}
+
+func TestRun_Bzip2_HexDump(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bz2", bzip2Abc)
+	stdout := &bytes.Buffer{}
+
+	// ACT
+	err := Run(context.Background(), input, input, Options{
+		TargetString: "b",
+		Replacement:  "B",
+		HexDump:      true,
+		Stdout:       stdout,
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	// 展開した内容でのオフセット
+	assert.Equal(t,
+		input+": offset 0x1: 1 bytes -> 1 bytes\n"+
+			"- 00000001: 62                                       b\n"+
+			"+ 00000001: 42                                       B\n",
+		stdout.String())
+}
+
+func TestRun_Compress_Dir(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	inputDir := filepath.Join(d, "input")
+	require.NoError(t, os.Mkdir(inputDir, 0755))
+	gzipBytes := createGzip(t, strings.Repeat("user=alice\n", 100), gzip.Header{})
+	createFileWriteBytes(t, inputDir, "a.log.gz", gzipBytes)
+	createFileWriteString(t, inputDir, "b.txt", "BZh alice\n")
+	outputDir := filepath.Join(d, "output")
+
+	// ACT
+	err := Run(context.Background(), inputDir, outputDir, Options{
+		TargetString: "alice",
+		Replacement:  "***",
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	// ディレクトリをたどる場合は、明示しない限り展開しない
+	assert.Equal(t, gzipBytes, readBytes(t, filepath.Join(outputDir, "a.log.gz")))
+	assert.Equal(t, "BZh ***\n", readString(t, filepath.Join(outputDir, "b.txt")))
+}
+
+func TestRun_Compress_Dir_Gzip(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	inputDir := filepath.Join(d, "input")
+	require.NoError(t, os.Mkdir(inputDir, 0755))
+	createFileWriteBytes(t, inputDir, "a.log.gz", createGzip(t, "user=alice\n", gzip.Header{}))
+	createFileWriteString(t, inputDir, "b.txt", "user=alice\n")
+	outputDir := filepath.Join(d, "output")
+
+	// ACT
+	err := Run(context.Background(), inputDir, outputDir, Options{
+		TargetString: "alice",
+		Replacement:  "***",
+		Compress:     "gzip",
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	reader, err := gzip.NewReader(bytes.NewReader(readBytes(t, filepath.Join(outputDir, "a.log.gz"))))
+	require.NoError(t, err)
+	contents, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "user=***\n", string(contents))
+
+	// 展開できないものはそのまま置換
+	assert.Equal(t, "user=***\n", readString(t, filepath.Join(outputDir, "b.txt")))
+}
+
+func TestRun_Gzip_Tar(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	inputBytes := createTarGz(t, []tarEntry{
+		{header: tar.Header{Name: "app.conf", Typeflag: tar.TypeReg, Mode: 0644}, content: "host=old.example.com\n"},
+	})
+	input := createFileWriteBytes(t, d, "input.tar.gz", inputBytes)
+	output := filepath.Join(d, "output.tar.gz")
+
+	// ACT
+	err := Run(context.Background(), input, output, Options{
+		TargetString: "old",
+		Replacement:  "new",
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	// アーカイブは --archive の場合のみ展開
+	assert.Equal(t, inputBytes, readBytes(t, output))
+}
+
+func TestRun_Bzip2_Invalid(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "BZh is not bzip2\n")
+	output := filepath.Join(d, "output.txt")
+
+	// ACT
+	err := Run(context.Background(), input, output, Options{
+		TargetString: "bzip2",
+		Replacement:  "gzip",
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	// 展開できないものはそのまま置換
+	assert.Equal(t, "BZh is not gzip\n", readString(t, output))
+}
+
+func TestRun_CompressInvalid(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "abc")
+
+	// ACT
+	err := Run(context.Background(), input, filepath.Join(d, "output.txt"), Options{
+		TargetString: "a",
+		Replacement:  "A",
+		Compress:     "xz",
+	})
+
+	// ASSERT
+	require.EqualError(t, err, "xz is not supported")
+}
+
+func createGzip(t *testing.T, contents string, header gzip.Header) []byte {
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	writer.Header = header
+	_, err := writer.Write([]byte(contents))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
+
+func readBytes(t *testing.T, name string) []byte {
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
@@ -0,0 +1,45 @@
+package rcf
+
+import (
+	"fmt"
+
+	"github.com/onozaty/rcf/replace"
+)
+
+// ファイルの置換に失敗
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+
+	return e.Err
+}
+
+// 長さが変わる置換があったため、ファイルを出力しなかった
+type LengthError struct {
+	Path       string
+	Violations []replace.LengthViolation
+}
+
+func (e *LengthError) Error() string {
+
+	return fmt.Sprintf("%s: %d replacements would change the length", e.Path, len(e.Violations))
+}
+
+// 名前を置換した結果、同じディレクトリに同じ名前のものができてしまう
+type NameConflictError struct {
+	Paths [2]string
+	Name  string
+}
+
+func (e *NameConflictError) Error() string {
+
+	return fmt.Sprintf("%s and %s would have the same name %s", e.Paths[0], e.Paths[1], e.Name)
+}
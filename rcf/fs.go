@@ -0,0 +1,188 @@
+package rcf
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// シンボリックリンクを扱えるFS
+type ReadLinkFS interface {
+	fs.FS
+	ReadLink(name string) (string, error)
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// 出力先のFS
+// 名前は fs.FS と同じく / 区切りの相対パス
+type WriteFS interface {
+	ReadLinkFS
+	Mkdir(name string, perm fs.FileMode) error
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Rename(oldname string, newname string) error
+	Remove(name string) error
+	Symlink(oldname string, newname string) error
+	Chmod(name string, mode fs.FileMode) error
+	Chtimes(name string, atime time.Time, mtime time.Time) error
+}
+
+// ディレクトリをルートとしたOSのファイルシステム
+type DirFS string
+
+func (d DirFS) path(op string, name string) (string, error) {
+
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	return filepath.Join(string(d), filepath.FromSlash(name)), nil
+}
+
+func (d DirFS) Open(name string) (fs.File, error) {
+
+	path, err := d.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(path)
+}
+
+func (d DirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+
+	path, err := d.path("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadDir(path)
+}
+
+func (d DirFS) ReadFile(name string) ([]byte, error) {
+
+	path, err := d.path("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path)
+}
+
+func (d DirFS) Stat(name string) (fs.FileInfo, error) {
+
+	path, err := d.path("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Stat(path)
+}
+
+func (d DirFS) Lstat(name string) (fs.FileInfo, error) {
+
+	path, err := d.path("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Lstat(path)
+}
+
+func (d DirFS) ReadLink(name string) (string, error) {
+
+	path, err := d.path("readlink", name)
+	if err != nil {
+		return "", err
+	}
+
+	return os.Readlink(path)
+}
+
+func (d DirFS) Mkdir(name string, perm fs.FileMode) error {
+
+	path, err := d.path("mkdir", name)
+	if err != nil {
+		return err
+	}
+
+	return os.Mkdir(path, perm)
+}
+
+func (d DirFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+
+	path, err := d.path("writefile", name)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, perm)
+}
+
+func (d DirFS) Rename(oldname string, newname string) error {
+
+	oldpath, err := d.path("rename", oldname)
+	if err != nil {
+		return err
+	}
+	newpath, err := d.path("rename", newname)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(oldpath, newpath)
+}
+
+func (d DirFS) Remove(name string) error {
+
+	path, err := d.path("remove", name)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// リンク先(oldname)はそのまま使う
+func (d DirFS) Symlink(oldname string, newname string) error {
+
+	newpath, err := d.path("symlink", newname)
+	if err != nil {
+		return err
+	}
+
+	return os.Symlink(oldname, newpath)
+}
+
+func (d DirFS) Chmod(name string, mode fs.FileMode) error {
+
+	path, err := d.path("chmod", name)
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(path, mode)
+}
+
+func (d DirFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+
+	path, err := d.path("chtimes", name)
+	if err != nil {
+		return err
+	}
+
+	return os.Chtimes(path, atime, mtime)
+}
+
+// エラーメッセージなどで表示するパス (OSのファイルシステムの場合はOSのパス)
+func displayPath(fsys fs.FS, name string) string {
+
+	if d, ok := fsys.(DirFS); ok {
+		return filepath.Join(string(d), filepath.FromSlash(name))
+	}
+	if a, ok := fsys.(*archiveFS); ok {
+		return a.displayPath(name)
+	}
+
+	return name
+}
@@ -0,0 +1,47 @@
+package rcf
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirFS(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	dir := DirFS(d)
+
+	// ACT
+	require.NoError(t, dir.Mkdir("a", 0755))
+	require.NoError(t, dir.WriteFile("a/1.txt", []byte("1"), 0644))
+	require.NoError(t, dir.Rename("a/1.txt", "a/2.txt"))
+
+	// ASSERT
+	require.NoError(t, fstest.TestFS(dir, "a", "a/2.txt"))
+	assert.Equal(t, "1", readString(t, filepath.Join(d, "a", "2.txt")))
+	assert.Equal(t, filepath.Join(d, "a", "2.txt"), displayPath(dir, "a/2.txt"))
+}
+
+func TestDirFS_Invalid(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	dir := DirFS(filepath.Join(d, "x"))
+
+	// ACT / ASSERT
+	_, err := dir.ReadFile("../1.txt")
+	assert.ErrorIs(t, err, fs.ErrInvalid)
+
+	err = dir.WriteFile("/1.txt", []byte{}, 0644)
+	assert.ErrorIs(t, err, fs.ErrInvalid)
+}
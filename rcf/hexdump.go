@@ -0,0 +1,39 @@
+package rcf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xxd と同じ形式で、16バイトごとにオフセット、16進数、ASCIIを出力
+func writeHexDump(w io.Writer, prefix string, offset int, b []byte) {
+
+	for i := 0; i < len(b); i += 16 {
+		end := i + 16
+		if end > len(b) {
+			end = len(b)
+		}
+		line := b[i:end]
+
+		var hex strings.Builder
+		var ascii strings.Builder
+		for j := 0; j < 16; j++ {
+			if j < len(line) {
+				fmt.Fprintf(&hex, "%02x", line[j])
+				if line[j] >= 0x20 && line[j] <= 0x7E {
+					ascii.WriteByte(line[j])
+				} else {
+					ascii.WriteByte('.')
+				}
+			} else {
+				hex.WriteString("  ")
+			}
+			if j%2 == 1 {
+				hex.WriteByte(' ')
+			}
+		}
+
+		fmt.Fprintf(w, "%s%08x: %s %s\n", prefix, offset+i, hex.String(), ascii.String())
+	}
+}
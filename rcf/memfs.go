@@ -0,0 +1,318 @@
+package rcf
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// メモリ上のファイルシステム (テストやプレビュー用)
+type MemFS struct {
+	entries map[string]*memEntry
+}
+
+type memEntry struct {
+	data    []byte // シンボリックリンクの場合はリンク先
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func NewMemFS() *MemFS {
+
+	return &MemFS{
+		entries: map[string]*memEntry{
+			".": {mode: fs.ModeDir | 0777, modTime: time.Now()},
+		},
+	}
+}
+
+func (m *MemFS) lookup(op string, name string) (*memEntry, error) {
+
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	entry, ok := m.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return entry, nil
+}
+
+// 作成する場合、親のディレクトリが存在する必要がある
+func (m *MemFS) checkParent(op string, name string) error {
+
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	parent, ok := m.entries[path.Dir(name)]
+	if !ok || !parent.mode.IsDir() {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return nil
+}
+
+func (m *MemFS) Open(name string) (fs.File, error) {
+
+	entry, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &memFile{info: &memFileInfo{name: path.Base(name), entry: entry}}
+	if entry.mode.IsDir() {
+		file.entries, _ = m.ReadDir(name)
+	} else {
+		file.reader = bytes.NewReader(entry.data)
+	}
+
+	return file, nil
+}
+
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+
+	entry, err := m.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entries := []fs.DirEntry{}
+	for childName, child := range m.entries {
+		if childName != "." && path.Dir(childName) == name {
+			entries = append(entries, fs.FileInfoToDirEntry(&memFileInfo{name: path.Base(childName), entry: child}))
+		}
+	}
+
+	// os.ReadDir と同じく名前順
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+
+	entry, err := m.lookup("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return append([]byte{}, entry.data...), nil
+}
+
+// シンボリックリンクはたどらない
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+
+	return m.Lstat(name)
+}
+
+func (m *MemFS) Lstat(name string) (fs.FileInfo, error) {
+
+	entry, err := m.lookup("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memFileInfo{name: path.Base(name), entry: entry}, nil
+}
+
+func (m *MemFS) ReadLink(name string) (string, error) {
+
+	entry, err := m.lookup("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	if entry.mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return string(entry.data), nil
+}
+
+func (m *MemFS) Mkdir(name string, perm fs.FileMode) error {
+
+	if err := m.checkParent("mkdir", name); err != nil {
+		return err
+	}
+	if _, ok := m.entries[name]; ok {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	}
+
+	m.entries[name] = &memEntry{mode: fs.ModeDir | perm.Perm(), modTime: time.Now()}
+	return nil
+}
+
+func (m *MemFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+
+	if err := m.checkParent("writefile", name); err != nil {
+		return err
+	}
+
+	if entry, ok := m.entries[name]; ok {
+		if !entry.mode.IsRegular() {
+			return &fs.PathError{Op: "writefile", Path: name, Err: fs.ErrInvalid}
+		}
+		// 既存のファイルは権限をそのままにする (os.WriteFile と同じ)
+		entry.data = append([]byte{}, data...)
+		entry.modTime = time.Now()
+		return nil
+	}
+
+	m.entries[name] = &memEntry{data: append([]byte{}, data...), mode: perm.Perm(), modTime: time.Now()}
+	return nil
+}
+
+func (m *MemFS) Rename(oldname string, newname string) error {
+
+	entry, err := m.lookup("rename", oldname)
+	if err != nil {
+		return err
+	}
+	if err := m.checkParent("rename", newname); err != nil {
+		return err
+	}
+	if _, ok := m.entries[newname]; ok {
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrExist}
+	}
+
+	// ディレクトリの場合は配下も移動
+	moved := map[string]*memEntry{newname: entry}
+	for childName, child := range m.entries {
+		if strings.HasPrefix(childName, oldname+"/") {
+			moved[newname+strings.TrimPrefix(childName, oldname)] = child
+			delete(m.entries, childName)
+		}
+	}
+	delete(m.entries, oldname)
+	for movedName, movedEntry := range moved {
+		m.entries[movedName] = movedEntry
+	}
+
+	return nil
+}
+
+func (m *MemFS) Remove(name string) error {
+
+	entry, err := m.lookup("remove", name)
+	if err != nil {
+		return err
+	}
+
+	if entry.mode.IsDir() {
+		for childName := range m.entries {
+			if strings.HasPrefix(childName, name+"/") {
+				return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrExist}
+			}
+		}
+	}
+
+	delete(m.entries, name)
+	return nil
+}
+
+func (m *MemFS) Symlink(oldname string, newname string) error {
+
+	if err := m.checkParent("symlink", newname); err != nil {
+		return err
+	}
+	if _, ok := m.entries[newname]; ok {
+		return &fs.PathError{Op: "symlink", Path: newname, Err: fs.ErrExist}
+	}
+
+	m.entries[newname] = &memEntry{data: []byte(oldname), mode: fs.ModeSymlink | 0777, modTime: time.Now()}
+	return nil
+}
+
+func (m *MemFS) Chmod(name string, mode fs.FileMode) error {
+
+	entry, err := m.lookup("chmod", name)
+	if err != nil {
+		return err
+	}
+
+	entry.mode = entry.mode.Type() | mode.Perm()
+	return nil
+}
+
+func (m *MemFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+
+	entry, err := m.lookup("chtimes", name)
+	if err != nil {
+		return err
+	}
+
+	entry.modTime = mtime
+	return nil
+}
+
+type memFileInfo struct {
+	name  string
+	entry *memEntry
+}
+
+func (i *memFileInfo) Name() string       { return i.name }
+func (i *memFileInfo) Size() int64        { return int64(len(i.entry.data)) }
+func (i *memFileInfo) Mode() fs.FileMode  { return i.entry.mode }
+func (i *memFileInfo) ModTime() time.Time { return i.entry.modTime }
+func (i *memFileInfo) IsDir() bool        { return i.entry.mode.IsDir() }
+func (i *memFileInfo) Sys() interface{}   { return nil }
+
+type memFile struct {
+	info    *memFileInfo
+	reader  *bytes.Reader
+	entries []fs.DirEntry
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+
+	return f.info, nil
+}
+
+func (f *memFile) Read(b []byte) (int, error) {
+
+	if f.reader == nil {
+		return 0, &fs.PathError{Op: "read", Path: f.info.name, Err: fs.ErrInvalid}
+	}
+
+	return f.reader.Read(b)
+}
+
+func (f *memFile) ReadDir(n int) ([]fs.DirEntry, error) {
+
+	if n <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(f.entries) {
+		n = len(f.entries)
+	}
+	entries := f.entries[:n]
+	f.entries = f.entries[n:]
+
+	return entries, nil
+}
+
+func (f *memFile) Close() error {
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package rcf
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemFS(t *testing.T) {
+
+	// ARRANGE
+	m := NewMemFS()
+
+	// ACT
+	require.NoError(t, m.Mkdir("a", 0755))
+	require.NoError(t, m.WriteFile("a/1.txt", []byte("1"), 0644))
+	require.NoError(t, m.WriteFile("b.txt", []byte("b"), 0600))
+	require.NoError(t, m.Symlink("a/1.txt", "c"))
+
+	// ASSERT
+	// fs.FS として正しく動作すること
+	require.NoError(t, fstest.TestFS(m, "a", "a/1.txt", "b.txt"))
+
+	data, err := fs.ReadFile(m, "a/1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), data)
+
+	info, err := m.Lstat("b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0600), info.Mode())
+
+	target, err := m.ReadLink("c")
+	require.NoError(t, err)
+	assert.Equal(t, "a/1.txt", target)
+}
+
+func TestMemFS_Rename(t *testing.T) {
+
+	// ARRANGE
+	m := NewMemFS()
+	require.NoError(t, m.Mkdir("a", 0755))
+	require.NoError(t, m.Mkdir("a/b", 0755))
+	require.NoError(t, m.WriteFile("a/b/1.txt", []byte("1"), 0644))
+
+	// ACT
+	require.NoError(t, m.Rename("a", "x"))
+
+	// ASSERT
+	data, err := fs.ReadFile(m, "x/b/1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), data)
+
+	_, err = m.Lstat("a/b/1.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestMemFS_Metadata(t *testing.T) {
+
+	// ARRANGE
+	m := NewMemFS()
+	require.NoError(t, m.WriteFile("1.txt", []byte("1"), 0644))
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// ACT
+	require.NoError(t, m.Chmod("1.txt", 0600))
+	require.NoError(t, m.Chtimes("1.txt", modTime, modTime))
+	// 既存のファイルへの書き込みでは権限は変わらない
+	require.NoError(t, m.WriteFile("1.txt", []byte("2"), 0666))
+
+	// ASSERT
+	info, err := m.Lstat("1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0600), info.Mode())
+}
+
+func TestMemFS_Invalid(t *testing.T) {
+
+	m := NewMemFS()
+
+	assert.ErrorIs(t, m.WriteFile("a/1.txt", []byte{}, 0644), fs.ErrNotExist)
+	assert.ErrorIs(t, m.WriteFile("../1.txt", []byte{}, 0644), fs.ErrInvalid)
+	assert.ErrorIs(t, m.Mkdir(".", 0755), fs.ErrInvalid)
+
+	require.NoError(t, m.Mkdir("a", 0755))
+	assert.ErrorIs(t, m.Mkdir("a", 0755), fs.ErrExist)
+
+	require.NoError(t, m.WriteFile("a/1.txt", []byte{}, 0644))
+	assert.ErrorIs(t, m.Remove("a"), fs.ErrExist)
+}
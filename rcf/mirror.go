@@ -0,0 +1,154 @@
+package rcf
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 置換せずにディレクトリをそのままコピー
+func (p *processor) copyDir(inputDirName string, outputDirName string) error {
+
+	entries, err := fs.ReadDir(p.in, inputDirName)
+	if err != nil {
+		return err
+	}
+
+	if err := p.mkdirIfNotExist(outputDirName); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		inputEntryName := path.Join(inputDirName, entry.Name())
+		outputEntryName := path.Join(outputDirName, entry.Name())
+
+		switch {
+		case entry.Type()&fs.ModeSymlink != 0:
+			err = p.copySymlink(inputEntryName, outputEntryName)
+		case entry.IsDir():
+			err = p.copyDir(inputEntryName, outputEntryName)
+		case entry.Type().IsRegular():
+			err = p.copyFile(inputEntryName, outputEntryName)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.copyMetadata(inputDirName, outputDirName, true)
+}
+
+func (p *processor) copyFile(inputFileName string, outputFileName string) error {
+
+	contents, err := fs.ReadFile(p.in, inputFileName)
+	if err != nil {
+		return err
+	}
+
+	if err := p.out.WriteFile(outputFileName, contents, 0666); err != nil {
+		return err
+	}
+
+	return p.copyMetadata(inputFileName, outputFileName, true)
+}
+
+func (p *processor) copySymlink(inputLinkName string, outputLinkName string) error {
+
+	in, ok := p.in.(ReadLinkFS)
+	if !ok {
+		// リンクを読めないFSの場合は対象外
+		return nil
+	}
+
+	target, err := in.ReadLink(inputLinkName)
+	if err != nil {
+		return err
+	}
+
+	target, err = p.replaceLinkTarget(inputLinkName, target)
+	if err != nil {
+		return err
+	}
+
+	// 既にある場合は作り直す
+	if _, err := p.out.Lstat(outputLinkName); err == nil {
+		if err := p.out.Remove(outputLinkName); err != nil {
+			return err
+		}
+	}
+
+	return p.out.Symlink(target, outputLinkName)
+}
+
+// 名前を置換する場合は、相対パスのリンク先も置換後の名前に合わせる
+// (絶対パスや入力の外を指すものはそのまま)
+func (p *processor) replaceLinkTarget(linkName string, target string) (string, error) {
+
+	if p.pathReplacer == nil || path.IsAbs(target) || filepath.IsAbs(target) {
+		return target, nil
+	}
+
+	dir := path.Dir(linkName)
+	elements := strings.Split(filepath.ToSlash(target), "/")
+	if resolved := path.Join(dir, strings.Join(elements, "/")); resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return target, nil
+	}
+
+	for i, element := range elements {
+		name := path.Join(dir, element)
+		dir = name
+		if element == "" || element == "." || element == ".." {
+			continue
+		}
+
+		if !p.recursive {
+			// たどるのは入力のディレクトリのみで、その中のディレクトリの名前は置換しない
+			if path.Dir(name) != "." || i != len(elements)-1 {
+				continue
+			}
+			if info, err := fs.Stat(p.in, name); err == nil && info.IsDir() {
+				continue
+			}
+		}
+
+		replaced, err := p.replaceName(element)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", displayPath(p.in, linkName), err)
+		}
+		elements[i] = replaced
+	}
+
+	return filepath.FromSlash(strings.Join(elements, "/")), nil
+}
+
+// 権限 (と更新日時) を合わせる
+func (p *processor) copyMetadata(inputName string, outputName string, withTimes bool) error {
+
+	info, err := fs.Stat(p.in, inputName)
+	if err != nil {
+		return err
+	}
+
+	if err := p.out.Chmod(outputName, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	if withTimes {
+		return p.out.Chtimes(outputName, info.ModTime(), info.ModTime())
+	}
+
+	return nil
+}
+
+func (p *processor) mkdirIfNotExist(dirName string) error {
+
+	_, err := fs.Stat(p.out, dirName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return p.out.Mkdir(dirName, fs.ModePerm)
+	}
+
+	return err
+}
@@ -0,0 +1,231 @@
+package rcf
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// 置換の条件
+// 未指定(ゼロ値)の項目は、コマンドラインのデフォルトと同じ値として扱う
+type Options struct {
+	// 対象 (いずれか)
+	TargetRegex   string
+	TargetString  string
+	BinaryPattern string
+
+	Replacement string
+	Operation   string // replace, delete-line, insert-before, insert-after, ensure-present, ensure-absent (デフォルト replace)
+	Multiline   bool
+	DotAll      bool
+
+	// オフセットを指定したバイト列の書き換え
+	PatchAt     string
+	PatchBytes  string
+	PatchExpect string
+	PatchFile   string
+
+	PreserveLength bool
+	Pad            string
+
+	// ファイルは出力せず、置換した箇所の16進ダンプをStdoutに出力
+	HexDump     bool
+	DumpContext int
+
+	// 置換する範囲
+	LineMode     bool
+	LineStart    int
+	LineEnd      int
+	BetweenStart string
+	BetweenEnd   string
+	After        string
+	Before       string
+	Inclusive    bool
+	SourceScope  string
+
+	// 置換する数
+	Occurrence int
+	MaxCount   int
+	MaxTotal   int
+
+	// 置換する条件
+	If           string
+	Unless       string
+	FilesWith    string
+	FilesWithout string
+
+	// 構造を解析して値のみを置換
+	Format       string // json, yaml, csv, xml, html, properties, ini, dotenv
+	Path         string // デフォルト $..*
+	Columns      []string
+	CSVDelimiter string // デフォルト ,
+	CSVQuote     string // デフォルト "
+	CSVNoHeader  bool
+	Selector     string
+	Attrs        []string
+	Keys         []string
+	Sections     []string
+
+	RenamePaths bool
+	PathsOnly   bool
+	Mirror      bool
+	GoRename    bool
+
+	// ファイルは出力せず、変更される名前をStdoutに出力 (RenamePaths, PathsOnly の場合のみ)
+	// GoRename の場合は、変更されるファイルを出力
+	DryRun bool
+
+	// 入力のzip, tar, tar.gzのエントリを置換して、同じ形式のアーカイブで出力
+	Archive bool
+
+	Charset         string // デフォルト UTF-8 (xml, html の場合は auto、properties の場合は latin1)
+	Compress        string // auto, none, gzip, bzip2 (デフォルト auto、ディレクトリの場合 auto は none と同じ)
+	Recursive       bool
+	CheckIdempotent bool
+
+	// 16進ダンプや警告の出力先 (デフォルト os.Stdout, os.Stderr)
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// ファイルごとの処理結果の通知
+	OnFile func(FileEvent)
+}
+
+type FileAction int
+
+const (
+	// 置換して出力
+	FileReplaced FileAction = iota
+	// 内容はそのままコピー
+	FileCopied
+	// 対象外のため出力しない
+	FileSkipped
+	// 16進ダンプを出力 (ファイルは出力しない)
+	FileDumped
+)
+
+type FileEvent struct {
+	InputPath  string
+	OutputPath string
+	Action     FileAction
+}
+
+func (o Options) withDefaults() Options {
+
+	if o.Operation == "" {
+		o.Operation = "replace"
+	}
+	if o.Path == "" {
+		o.Path = "$..*"
+	}
+	if o.CSVDelimiter == "" {
+		o.CSVDelimiter = ","
+	}
+	if o.CSVQuote == "" {
+		o.CSVQuote = `"`
+	}
+	if o.Charset == "" {
+		o.Charset = "UTF-8"
+		if o.Format == "xml" || o.Format == "html" {
+			// XMLやHTMLは、文書内で宣言された文字コードに従う
+			o.Charset = "auto"
+		}
+		if o.Format == "properties" {
+			// propertiesファイルはISO-8859-1
+			o.Charset = "latin1"
+		}
+	}
+	if o.Compress == "" {
+		o.Compress = "auto"
+	}
+	if o.PathsOnly {
+		o.RenamePaths = true
+	}
+	if o.Stdout == nil {
+		o.Stdout = os.Stdout
+	}
+	if o.Stderr == nil {
+		o.Stderr = os.Stderr
+	}
+
+	return o
+}
+
+// 同時に指定できない項目の組み合わせを確認
+func (o Options) validate() error {
+
+	if (o.BetweenStart == "") != (o.BetweenEnd == "") {
+		// 開始と終了のマーカーはセットで指定
+		return fmt.Errorf("--between-start and --between-end must be specified together")
+	}
+
+	if o.MaxCount < 0 || o.MaxTotal < 0 {
+		return fmt.Errorf("--max-count and --max-total must not be negative")
+	}
+
+	if (o.PatchAt == "") != (o.PatchBytes == "") {
+		// オフセットと書き込むバイト列はセットで指定
+		return fmt.Errorf("--patch-at and --patch-bytes must be specified together")
+	}
+
+	if o.Pad != "" && !o.PreserveLength {
+		// 埋める指定は長さを維持する場合のみ
+		return fmt.Errorf("--pad requires --preserve-length")
+	}
+
+	if o.DumpContext < 0 {
+		return fmt.Errorf("--dump-context must not be negative")
+	}
+
+	if o.PreserveLength {
+		if o.Operation != "replace" {
+			// 行の削除などは、マッチ単位で長さを確認できない
+			return fmt.Errorf("--preserve-length can only be used with --operation replace")
+		}
+		if o.Format != "" {
+			// 値はエスケープなどを解除したものなので、ファイルでの位置や長さにならない
+			return fmt.Errorf("--preserve-length cannot be used with --format")
+		}
+	}
+
+	if o.BinaryPattern != "" && o.Operation != "replace" {
+		return fmt.Errorf("--binary-pattern can only be used with --operation replace")
+	}
+
+	if o.RenamePaths && o.Operation != "replace" {
+		return fmt.Errorf("--rename-paths can only be used with --operation replace")
+	}
+
+	if o.DryRun && !o.RenamePaths && !o.GoRename {
+		return fmt.Errorf("--dry-run requires --rename-paths, --paths-only or --go-rename")
+	}
+
+	if o.HexDump && (o.Operation != "replace" || o.LineMode || o.LineStart != 0 || o.LineEnd != 0 || o.BetweenStart != "" ||
+		o.After != "" || o.Before != "" || o.SourceScope != "" || o.Format != "" || o.PreserveLength ||
+		o.PatchAt != "" || o.PatchFile != "" || o.GoRename || o.RenamePaths || o.Mirror) {
+		// 置換した箇所をファイル全体での位置として扱えるもののみ
+		return fmt.Errorf("--hex-dump cannot be used with line, scope, format, patch, length and rename options")
+	}
+
+	if o.Archive && (o.GoRename || o.RenamePaths || o.Mirror) {
+		// アーカイブ内のエントリは名前を変えずに置換
+		return fmt.Errorf("--archive cannot be used with rename and mirror options")
+	}
+
+	if o.GoRename {
+		// 識別子の変更はGoのソースとして解析するので、内容の置換に関するオプションは使えない
+		if o.TargetRegex != "" || o.BinaryPattern != "" || o.Operation != "replace" || o.Multiline || o.DotAll ||
+			o.PatchAt != "" || o.PatchFile != "" || o.PreserveLength || o.LineMode || o.LineStart != 0 || o.LineEnd != 0 ||
+			o.BetweenStart != "" || o.After != "" || o.Before != "" || o.SourceScope != "" ||
+			o.Occurrence != 0 || o.MaxCount != 0 || o.MaxTotal != 0 || o.If != "" || o.Unless != "" ||
+			o.FilesWith != "" || o.FilesWithout != "" || o.Format != "" || o.RenamePaths || o.CheckIdempotent {
+			return fmt.Errorf("--go-rename cannot be used with content replacement options")
+		}
+		if o.TargetString == "" {
+			// 名前の変更は識別子で指定
+			return fmt.Errorf("--go-rename requires --string")
+		}
+	}
+
+	return nil
+}
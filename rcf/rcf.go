@@ -0,0 +1,1120 @@
+package rcf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/onozaty/rcf/encoder"
+	"github.com/onozaty/rcf/format"
+	"github.com/onozaty/rcf/gorename"
+	r "github.com/onozaty/rcf/replace"
+	"github.com/onozaty/rcf/syntax"
+)
+
+// 入力のファイルまたはディレクトリを置換して出力先に書き込む (入力と出力が同じ場合は上書き)
+func Run(ctx context.Context, inputPath string, outputPath string, options Options) error {
+
+	options = options.withDefaults()
+	if err := options.validate(); err != nil {
+		return err
+	}
+
+	if options.GoRename {
+		return renameGo(ctx, inputPath, outputPath, options)
+	}
+
+	inputInfo, err := os.Stat(inputPath)
+	if err != nil {
+		return err
+	}
+
+	if !inputInfo.IsDir() && options.Archive {
+		return replaceArchive(ctx, inputPath, outputPath, options)
+	}
+
+	if !inputInfo.IsDir() {
+		// ファイル指定の場合は、ファイルのあるディレクトリをルートとする
+		in := DirFS(filepath.Dir(inputPath))
+		out := DirFS(filepath.Dir(outputPath))
+		return replaceFS(ctx, in, filepath.Base(inputPath), out, filepath.Base(outputPath), inputPath == outputPath, options)
+	}
+
+	// ディレクトリ指定
+	return replaceFS(ctx, DirFS(inputPath), ".", DirFS(outputPath), ".", inputPath == outputPath, options)
+}
+
+// 入力のFSのファイルを全て置換して出力先のFSに書き込む
+// outにnilを指定した場合は、入力のFSに上書き (入力のFSはWriteFSである必要がある)
+func ReplaceFS(ctx context.Context, in fs.FS, out WriteFS, options Options) error {
+
+	options = options.withDefaults()
+	if err := options.validate(); err != nil {
+		return err
+	}
+
+	if options.GoRename {
+		return fmt.Errorf("--go-rename cannot be used with fs.FS")
+	}
+
+	inPlace := out == nil
+	if inPlace {
+		writable, ok := in.(WriteFS)
+		if !ok {
+			return fmt.Errorf("input fs.FS is not writable")
+		}
+		out = writable
+	}
+
+	return replaceFS(ctx, in, ".", out, ".", inPlace, options)
+}
+
+func replaceFS(ctx context.Context, in fs.FS, inputName string, out WriteFS, outputName string, inPlace bool, options Options) error {
+
+	processor, err := newProcessor(ctx, options)
+	if err != nil {
+		return err
+	}
+	processor.in = in
+	processor.out = out
+	processor.inPlace = inPlace
+
+	inputInfo, err := fs.Stat(in, inputName)
+	if err != nil {
+		return err
+	}
+
+	if inputInfo.IsDir() {
+		if processor.compress == compressAuto {
+			// たどったファイルを全て判定すると、tar.gz などを壊してしまうので、明示した場合のみ展開
+			processor.compress = compressNone
+		}
+		return processor.replaceFiles(inputName, outputName)
+	}
+
+	if processor.pathReplacer != nil && inPlace {
+		// 上書きの場合はファイル名も変更 (出力先が指定されている場合はそのまま)
+		replacedName, err := processor.replaceName(path.Base(inputName))
+		if err != nil {
+			return err
+		}
+		outputName = path.Join(path.Dir(inputName), replacedName)
+		if processor.dryRun {
+			processor.showRename(inputName, outputName)
+			return nil
+		}
+		if outputName != inputName {
+			if err := processor.renameEntry(inputName, outputName); err != nil {
+				return err
+			}
+			inputName = outputName
+		}
+	}
+	return processor.replaceFile(inputName, outputName)
+}
+
+// Readerの内容を置換してWriterに書き込む (対象外の場合はそのまま書き込む)
+func ReplaceReader(ctx context.Context, in io.Reader, out io.Writer, options Options) error {
+
+	options = options.withDefaults()
+	if err := options.validate(); err != nil {
+		return err
+	}
+
+	processor, err := newProcessor(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	inputBytes, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	// ファイル名の代わりに "-" とする
+	outputBytes, action, err := processor.replaceCompressedBytes("-", inputBytes)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case FileReplaced:
+		_, err = out.Write(outputBytes)
+	case FileCopied, FileSkipped:
+		_, err = out.Write(inputBytes)
+	}
+
+	return err
+}
+
+func newProcessor(ctx context.Context, options Options) (*processor, error) {
+
+	if err := validateCompress(options.Compress); err != nil {
+		return nil, err
+	}
+
+	encoder, err := newEncoder(options)
+	if err != nil {
+		return nil, err
+	}
+
+	syntaxScope, err := newSyntaxScope(options)
+	if err != nil {
+		return nil, err
+	}
+
+	lengthChecker, err := newLengthChecker(options, encoder)
+	if err != nil {
+		return nil, err
+	}
+
+	var recorder *r.MatchRecorder
+	if options.HexDump {
+		recorder = r.NewMatchRecorder()
+	}
+
+	format, err := newFormat(options)
+	if err != nil {
+		return nil, err
+	}
+
+	replacer, err := newReplacer(options, syntaxScope, format, lengthChecker, recorder)
+	if err != nil {
+		return nil, err
+	}
+
+	var checker *contentReplacer
+	if options.CheckIdempotent {
+		// 置換数の制限などの状態を共有しないよう、確認用は別に作成
+		checker, err = newReplacer(options, syntaxScope, format, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	filter, err := newFileFilter(options)
+	if err != nil {
+		return nil, err
+	}
+
+	pathReplacer, err := newPathReplacer(options)
+	if err != nil {
+		return nil, err
+	}
+
+	patches, err := newPatches(options)
+	if err != nil {
+		return nil, err
+	}
+
+	var byteReplacer r.BytesReplacer
+	if !options.CheckIdempotent && !options.HexDump {
+		byteReplacer, err = newBytesReplacer(options, encoder)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &processor{
+		ctx:          ctx,
+		replacer:     replacer,
+		byteReplacer: byteReplacer,
+		checker:      checker,
+		filter:       filter,
+		syntax:       syntaxScope,
+		pathReplacer: pathReplacer,
+		pathsOnly:    options.PathsOnly,
+		dryRun:       options.DryRun,
+		patches:      patches,
+		length:       lengthChecker,
+		recorder:     recorder,
+		dumpContext:  options.DumpContext,
+		mirror:       options.Mirror,
+		encoder:      encoder,
+		compress:     options.Compress,
+		recursive:    options.Recursive,
+		stdout:       options.Stdout,
+		stderr:       options.Stderr,
+		onFile:       options.OnFile,
+	}, nil
+}
+
+func renameGo(ctx context.Context, inputPath string, outputPath string, options Options) error {
+
+	inputInfo, err := os.Stat(inputPath)
+	if err != nil {
+		return err
+	}
+
+	if !inputInfo.IsDir() {
+		// パッケージ単位で解析するので、ディレクトリのみ
+		return fmt.Errorf("--go-rename requires a directory as input")
+	}
+
+	renamer, err := gorename.NewRenamer(options.TargetString, options.Replacement)
+	if err != nil {
+		return err
+	}
+
+	absInputPath, err := filepath.Abs(inputPath)
+	if err != nil {
+		return err
+	}
+
+	results, err := renamer.Rename(absInputPath, options.Recursive)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if options.DryRun {
+		// 書き込まずに、変更されるファイルのみ表示
+		changed := []string{}
+		for inputFilePath := range results {
+			relPath, err := filepath.Rel(absInputPath, inputFilePath)
+			if err != nil {
+				return err
+			}
+			changed = append(changed, filepath.Join(inputPath, relPath))
+		}
+		sort.Strings(changed)
+		for _, changedPath := range changed {
+			fmt.Fprintln(options.Stdout, changedPath)
+		}
+		return nil
+	}
+
+	processor, err := newProcessor(ctx, options)
+	if err != nil {
+		return err
+	}
+	processor.in = DirFS(inputPath)
+	processor.out = DirFS(outputPath)
+	processor.inPlace = inputPath == outputPath
+
+	// 解析した結果を、通常の置換と同じようにディレクトリをたどって出力
+	processor.goRenamed = map[string][]byte{}
+	for inputFilePath, contents := range results {
+		relPath, err := filepath.Rel(absInputPath, inputFilePath)
+		if err != nil {
+			return err
+		}
+		processor.goRenamed[filepath.ToSlash(relPath)] = contents
+	}
+
+	return processor.replaceFiles(".", ".")
+}
+
+type processor struct {
+	ctx          context.Context
+	in           fs.FS
+	out          WriteFS
+	inPlace      bool // 入力と出力が同じ (上書き)
+	replacer     *contentReplacer
+	byteReplacer r.BytesReplacer  // バイト列のまま置換できる場合のみ
+	checker      *contentReplacer // 冪等性を確認する場合のみ
+	filter       *fileFilter
+	syntax       *syntax.Scope // ソースコードの一部のみを置換する場合のみ
+	pathReplacer r.Replacer    // ファイル名やディレクトリ名も置換する場合のみ
+	pathsOnly    bool
+	dryRun       bool             // 名前の変更を表示するのみ
+	patches      []*r.Patch       // オフセットを指定して書き換える場合のみ
+	length       *r.LengthChecker // 長さを維持する場合のみ
+	recorder     *r.MatchRecorder // 16進ダンプを表示する場合のみ
+	dumpContext  int
+	mirror       bool
+	encoder      encoder.Encoder
+	compress     string            // auto の場合はマジックナンバーで判定
+	goRenamed    map[string][]byte // Goの識別子を変更する場合のみ (変更のあったファイルの内容)
+	recursive    bool
+	stdout       io.Writer
+	stderr       io.Writer
+	onFile       func(FileEvent)
+}
+
+func (p *processor) replaceFiles(inputDirName string, outputDirName string) error {
+
+	entries, err := fs.ReadDir(p.in, inputDirName)
+	if err != nil {
+		return err
+	}
+
+	if !p.dryRun {
+		// 出力先のディレクトリが無かったら作っておく
+		if err := p.mkdirIfNotExist(outputDirName); err != nil {
+			return err
+		}
+	}
+
+	outputNames, err := p.replaceNames(inputDirName, entries)
+	if err != nil {
+		return err
+	}
+
+	// 置換対象外のものもコピーするのは、入力と出力が別の場合のみ
+	mirror := p.mirror && !p.inPlace && !p.dryRun
+
+	for i, entry := range entries {
+		if err := p.ctx.Err(); err != nil {
+			return err
+		}
+
+		inputEntryName := path.Join(inputDirName, entry.Name())
+		outputEntryName := path.Join(outputDirName, outputNames[i])
+
+		if entry.IsDir() && !p.recursive {
+			if mirror {
+				if err := p.copyDir(inputEntryName, outputEntryName); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if p.dryRun {
+			// 書き込まずに、名前の変更のみ表示
+			p.showRename(inputEntryName, outputEntryName)
+			if entry.IsDir() {
+				if err := p.replaceFiles(inputEntryName, outputEntryName); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if p.inPlace && inputEntryName != outputEntryName {
+			// 上書きの場合は、名前を変更してから処理
+			if err := p.renameEntry(inputEntryName, outputEntryName); err != nil {
+				return err
+			}
+			inputEntryName = outputEntryName
+		}
+
+		switch {
+		case mirror && entry.Type()&fs.ModeSymlink != 0:
+			// シンボリックリンクはリンクのまま
+			if err := p.copySymlink(inputEntryName, outputEntryName); err != nil {
+				return err
+			}
+		case p.inPlace && p.pathReplacer != nil && entry.Type()&fs.ModeSymlink != 0:
+			// 名前を変更する場合は、リンク先を置換後の名前に合わせて作り直す (リンク先の内容は置換しない)
+			if err := p.copySymlink(inputEntryName, outputEntryName); err != nil {
+				return err
+			}
+		case mirror && !entry.IsDir() && !entry.Type().IsRegular():
+			// パイプやデバイスなどは対象外
+		case entry.IsDir():
+			// ディレクトリかつ再帰的にたどる場合
+			if err := p.replaceFiles(inputEntryName, outputEntryName); err != nil {
+				return err
+			}
+		default:
+			if err := p.replaceFile(inputEntryName, outputEntryName); err != nil {
+				return err
+			}
+		}
+	}
+
+	if mirror {
+		// 中身を書き込んだ後に、ディレクトリの権限や更新日時を合わせる
+		return p.copyMetadata(inputDirName, outputDirName, true)
+	}
+
+	return nil
+}
+
+// 出力先の名前 (名前を置換しない場合は元の名前)
+func (p *processor) replaceNames(dirName string, entries []fs.DirEntry) ([]string, error) {
+
+	names := make([]string, len(entries))
+	sources := map[string]string{}
+	for i, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || p.recursive {
+			// たどらないディレクトリの名前はそのまま
+			replaced, err := p.replaceName(entry.Name())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", displayPath(p.in, path.Join(dirName, entry.Name())), err)
+			}
+			name = replaced
+		}
+
+		// 同じ名前になってしまうものがあった場合はエラー
+		if source, ok := sources[name]; ok {
+			return nil, &NameConflictError{
+				Paths: [2]string{displayPath(p.in, path.Join(dirName, source)), displayPath(p.in, path.Join(dirName, entry.Name()))},
+				Name:  name,
+			}
+		}
+		sources[name] = entry.Name()
+		names[i] = name
+	}
+
+	return names, nil
+}
+
+func (p *processor) replaceName(name string) (string, error) {
+
+	if p.pathReplacer == nil {
+		return name, nil
+	}
+
+	replaced := p.pathReplacer.Replace(name)
+	if replaced == "" || replaced == "." || replaced == ".." || strings.ContainsAny(replaced, `/\`) {
+		return "", fmt.Errorf("invalid name \"%s\"", replaced)
+	}
+
+	return replaced, nil
+}
+
+func (p *processor) showRename(inputName string, outputName string) {
+
+	if path.Base(inputName) == path.Base(outputName) {
+		return
+	}
+
+	fmt.Fprintf(p.stdout, "%s -> %s\n", displayPath(p.in, inputName), displayPath(p.out, outputName))
+}
+
+// 上書きの場合のみ (入力と出力が同じFS)
+func (p *processor) renameEntry(oldName string, newName string) error {
+
+	if _, err := p.out.Lstat(newName); err == nil {
+		return fmt.Errorf("%s already exists", displayPath(p.out, newName))
+	}
+
+	return p.out.Rename(oldName, newName)
+}
+
+func (p *processor) replaceFile(inputFileName string, outputFileName string) error {
+
+	if err := p.ctx.Err(); err != nil {
+		return err
+	}
+
+	inputBytes, err := fs.ReadFile(p.in, inputFileName)
+	if err != nil {
+		return err
+	}
+
+	inputFilePath := displayPath(p.in, inputFileName)
+	outputFilePath := displayPath(p.out, outputFileName)
+
+	var outputBytes []byte
+	var action FileAction
+	if p.goRenamed != nil {
+		// 変更の無いファイルは内容をそのままコピー (上書きの場合は書き込まない)
+		action = FileCopied
+		if renamed, ok := p.goRenamed[inputFileName]; ok {
+			outputBytes, action = renamed, FileReplaced
+		}
+	} else {
+		outputBytes, action, err = p.replaceCompressedBytes(inputFilePath, inputBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch action {
+	case FileReplaced:
+		err = p.writeFile(inputFileName, outputFileName, outputBytes)
+	case FileCopied:
+		// 名前のみ置換する場合は、内容はそのまま
+		if !p.inPlace {
+			err = p.copyFile(inputFileName, outputFileName)
+		}
+	case FileSkipped:
+		// 対象外のファイルは出力しない (全てコピーする場合はそのまま出力)
+		if p.mirror && !p.inPlace {
+			err = p.copyFile(inputFileName, outputFileName)
+			action = FileCopied
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if p.onFile != nil {
+		p.onFile(FileEvent{InputPath: inputFilePath, OutputPath: outputFilePath, Action: action})
+	}
+
+	return nil
+}
+
+// 圧縮されている場合は、展開してから置換して同じ形式で圧縮
+func (p *processor) replaceCompressedBytes(inputFilePath string, inputBytes []byte) ([]byte, FileAction, error) {
+
+	format := p.compress
+	if format == compressAuto {
+		format = detectCompression(inputBytes)
+	}
+	if format == compressNone {
+		return p.replaceBytes(inputFilePath, inputBytes)
+	}
+	if format == compressXz || format == compressZstd {
+		// 圧縮されたまま置換すると壊してしまうので、処理しない
+		return nil, 0, &FileError{Path: inputFilePath, Err: fmt.Errorf("%s is not supported (use --compress none to process the bytes as they are)", format)}
+	}
+
+	c := &compressed{format: format}
+	contents, err := c.decompress(inputBytes)
+	if err != nil || isTar(contents) {
+		// 展開できないもの(マジックナンバーが偶然一致したものなど)や、アーカイブ(--archive で扱う)はそのまま
+		return p.replaceBytes(inputFilePath, inputBytes)
+	}
+
+	outputBytes, action, err := p.replaceBytes(inputFilePath, contents)
+	if err != nil || action != FileReplaced {
+		return outputBytes, action, err
+	}
+
+	if bytes.Equal(outputBytes, contents) {
+		// 変更が無い場合は、圧縮し直さずに元のまま
+		return inputBytes, action, nil
+	}
+
+	compressedBytes, err := c.compress(outputBytes)
+	if err != nil {
+		return nil, 0, &FileError{Path: inputFilePath, Err: err}
+	}
+
+	return compressedBytes, action, nil
+}
+
+// 置換後の内容と、その内容をどう扱うか
+func (p *processor) replaceBytes(inputFilePath string, inputBytes []byte) ([]byte, FileAction, error) {
+
+	if p.byteReplacer != nil {
+		// 文字列に変換せずに置換
+		return p.byteReplacer.ReplaceBytes(inputBytes), FileReplaced, nil
+	}
+
+	inputContents, err := p.encoder.String(inputBytes)
+	if err != nil {
+		return nil, 0, &FileError{Path: inputFilePath, Err: err}
+	}
+
+	if !p.filter.match(inputContents) {
+		return nil, FileSkipped, nil
+	}
+
+	if p.syntax != nil {
+		// 言語は拡張子で判定
+		p.syntax.SetPath(inputFilePath)
+	}
+
+	if p.pathsOnly {
+		return nil, FileCopied, nil
+	}
+
+	if p.length != nil {
+		p.length.Reset()
+	}
+	if p.recorder != nil {
+		p.recorder.Reset()
+	}
+
+	var outputContents string
+	if len(p.patches) != 0 {
+		outputContents, err = r.ApplyPatches(inputContents, p.patches)
+	} else {
+		outputContents, err = p.replacer.replace(inputContents)
+	}
+	if err != nil {
+		return nil, 0, &FileError{Path: inputFilePath, Err: err}
+	}
+
+	if p.length != nil && len(p.length.Violations()) != 0 {
+		// 長さが変わる箇所があれば、ファイルは出力しない
+		violations := []r.LengthViolation{}
+		for _, violation := range p.length.Violations() {
+			// 文字列での位置を、ファイルでのバイト数に変換
+			prefix, err := p.encoder.Bytes(inputContents[:violation.Offset])
+			if err != nil {
+				return nil, 0, &FileError{Path: inputFilePath, Err: err}
+			}
+			violation.Offset = len(prefix)
+			violations = append(violations, violation)
+
+			fmt.Fprintf(p.stderr, "%s: offset 0x%X: %d bytes -> %d bytes\n", inputFilePath, violation.Offset, violation.MatchLength, violation.ReplacementLength)
+		}
+		return nil, 0, &LengthError{Path: inputFilePath, Violations: violations}
+	}
+
+	if p.recorder != nil {
+		// ファイルは出力せず、置換した箇所を表示するのみ
+		return nil, FileDumped, p.dumpMatches(inputFilePath, inputContents, inputBytes)
+	}
+
+	if p.checker != nil {
+		// 再度置換した場合に結果が変わる場合は警告
+		rereplaced, err := p.checker.replace(outputContents)
+		if err == nil && rereplaced != outputContents {
+			fmt.Fprintf(p.stderr, "Warning: %s is not idempotent. Replacing again would change the result.\n", inputFilePath)
+		}
+	}
+
+	if outputContents == inputContents {
+		// 変更が無い場合は、文字コードとして不正なバイトなども含めて元のまま
+		return inputBytes, FileReplaced, nil
+	}
+
+	encodedBytes, err := p.encoder.Bytes(outputContents)
+	if err != nil {
+		return nil, 0, &FileError{Path: inputFilePath, Err: err}
+	}
+
+	return encodedBytes, FileReplaced, nil
+}
+
+func (p *processor) writeFile(inputFileName string, outputFileName string, contents []byte) error {
+
+	if err := p.out.WriteFile(outputFileName, contents, 0666); err != nil {
+		return err
+	}
+
+	if p.mirror && !p.inPlace {
+		// 内容を変更したファイルは、権限のみ合わせる
+		return p.copyMetadata(inputFileName, outputFileName, false)
+	}
+
+	return nil
+}
+
+func (p *processor) dumpMatches(inputFilePath string, contents string, inputBytes []byte) error {
+
+	// 文字列での位置を、バイト列での位置に変換
+	toBytes := func(s string) ([]byte, error) {
+		b, err := p.encoder.Bytes(s)
+		if err != nil {
+			return nil, &FileError{Path: inputFilePath, Err: fmt.Errorf("cannot convert the replacement to bytes: %w", err)}
+		}
+		return b, nil
+	}
+
+	offset := 0
+	last := 0
+	for _, m := range p.recorder.Matches() {
+		prefix, err := toBytes(contents[last:m.Start])
+		if err != nil {
+			return err
+		}
+		matched, err := toBytes(contents[m.Start:m.End])
+		if err != nil {
+			return err
+		}
+		replacement, err := toBytes(m.Replacement)
+		if err != nil {
+			return err
+		}
+
+		start := offset + len(prefix)
+		end := start + len(matched)
+		offset = end
+		last = m.End
+
+		from := start - p.dumpContext
+		if from < 0 {
+			from = 0
+		}
+		to := end + p.dumpContext
+		if to > len(inputBytes) {
+			to = len(inputBytes)
+		}
+
+		replaced := append(append(append([]byte{}, inputBytes[from:start]...), replacement...), inputBytes[end:to]...)
+
+		fmt.Fprintf(p.stdout, "%s: offset 0x%X: %d bytes -> %d bytes\n", inputFilePath, start, len(matched), len(replacement))
+		writeHexDump(p.stdout, "- ", from, inputBytes[from:to])
+		writeHexDump(p.stdout, "+ ", from, replaced)
+	}
+
+	return nil
+}
+
+// ファイルの内容の置換
+type contentReplacer struct {
+	replacer r.Replacer
+	split    *r.SplitReplacer // 構造を解析して値のみを置換する場合のみ
+}
+
+func (c *contentReplacer) replace(contents string) (string, error) {
+
+	replaced := c.replacer.Replace(contents)
+	if c.split != nil {
+		// 解析に失敗した場合など
+		if err := c.split.Err(); err != nil {
+			return "", err
+		}
+	}
+
+	return replaced, nil
+}
+
+type fileFilter struct {
+	with    r.Matcher
+	without r.Matcher
+}
+
+func newFileFilter(options Options) (*fileFilter, error) {
+
+	filter := &fileFilter{}
+
+	if options.FilesWith != "" {
+		matcher, err := r.NewRegexpMatcher(options.FilesWith)
+		if err != nil {
+			return nil, err
+		}
+		filter.with = matcher
+	}
+
+	if options.FilesWithout != "" {
+		matcher, err := r.NewRegexpMatcher(options.FilesWithout)
+		if err != nil {
+			return nil, err
+		}
+		filter.without = matcher
+	}
+
+	return filter, nil
+}
+
+func (f *fileFilter) match(contents string) bool {
+
+	if f.with != nil && !f.with.Match(contents) {
+		return false
+	}
+
+	if f.without != nil && f.without.Match(contents) {
+		return false
+	}
+
+	return true
+}
+
+func newFormat(options Options) (format.Format, error) {
+
+	switch options.Format {
+	case "":
+		return nil, nil
+	case "json":
+		return format.NewJSON(options.Path)
+	case "yaml":
+		return format.NewYAML(options.Path)
+	case "csv":
+		return format.NewCSV(options.Columns, options.CSVDelimiter, options.CSVQuote, !options.CSVNoHeader)
+	case "xml":
+		return format.NewXML(options.Selector, options.Attrs)
+	case "html":
+		return format.NewHTML(options.Selector, options.Attrs)
+	case "properties":
+		return format.NewProperties(options.Keys)
+	case "ini":
+		return format.NewINI(options.Sections, options.Keys)
+	case "dotenv":
+		return format.NewDotenv(options.Keys)
+	default:
+		return nil, fmt.Errorf("unknown format \"%s\"", options.Format)
+	}
+}
+
+func newPathReplacer(options Options) (r.Replacer, error) {
+
+	if !options.RenamePaths {
+		return nil, nil
+	}
+
+	// 範囲や置換数の指定は内容に対してのもの
+	return newBaseReplacer(options)
+}
+
+func newPatches(options Options) ([]*r.Patch, error) {
+
+	patches := []*r.Patch{}
+
+	if options.PatchAt != "" {
+		patch, err := r.NewPatch(options.PatchAt, options.PatchExpect, options.PatchBytes)
+		if err != nil {
+			return nil, err
+		}
+		patches = append(patches, patch)
+	}
+
+	if options.PatchFile != "" {
+		contents, err := os.ReadFile(options.PatchFile)
+		if err != nil {
+			return nil, err
+		}
+
+		filePatches, err := r.ParsePatches(string(contents))
+		if err != nil {
+			return nil, &FileError{Path: options.PatchFile, Err: err}
+		}
+		patches = append(patches, filePatches...)
+	}
+
+	return patches, nil
+}
+
+func newLengthChecker(options Options, enc encoder.Encoder) (*r.LengthChecker, error) {
+
+	if !options.PreserveLength {
+		return nil, nil
+	}
+
+	length := func(s string) int {
+		// 出力する文字コードでのバイト数
+		b, err := enc.Bytes(s)
+		if err != nil {
+			return -1
+		}
+		return len(b)
+	}
+
+	pad := ""
+	if options.Pad != "" {
+		b, err := strconv.ParseUint(strings.TrimPrefix(options.Pad, "x"), 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("--pad is invalid byte: %s", options.Pad)
+		}
+
+		pad, err = enc.String([]byte{byte(b)})
+		if err != nil || length(pad) != 1 {
+			return nil, fmt.Errorf("--pad cannot be used with the charset: %s", options.Pad)
+		}
+	}
+
+	return r.NewLengthChecker(length, pad), nil
+}
+
+// 範囲や条件の指定が無い単純な置換は、文字列に変換せずにバイト列のまま置換する
+func newBytesReplacer(options Options, enc encoder.Encoder) (r.BytesReplacer, error) {
+
+	if options.Operation != "replace" || options.LineMode ||
+		options.LineStart != 0 || options.LineEnd != 0 ||
+		options.BetweenStart != "" || options.After != "" || options.Before != "" ||
+		options.SourceScope != "" ||
+		options.Occurrence != 0 || options.MaxCount != 0 || options.MaxTotal != 0 ||
+		options.If != "" || options.Unless != "" ||
+		options.FilesWith != "" || options.FilesWithout != "" ||
+		options.Format != "" || options.PreserveLength || options.PathsOnly ||
+		options.PatchAt != "" || options.PatchFile != "" {
+		return nil, nil
+	}
+
+	if options.BinaryPattern != "" {
+		replacer, err := r.NewBinaryReplacer(options.BinaryPattern, options.Replacement)
+		if err != nil {
+			return nil, err
+		}
+		return replacer.(r.BytesReplacer), nil
+	}
+
+	if options.TargetRegex != "" || options.TargetString == "" {
+		return nil, nil
+	}
+
+	switch enc.(type) {
+	case *encoder.BinaryEncoder, *encoder.Latin1Encoder:
+		// 1バイトが決まった文字列になるものは、対象の文字列もバイト列に変換して置換できる
+	default:
+		return nil, nil
+	}
+
+	old, err := enc.Bytes(options.TargetString)
+	if err != nil {
+		// バイト列として解釈できないものは、文字列のまま置換
+		return nil, nil
+	}
+	new, err := enc.Bytes(options.Replacement)
+	if err != nil {
+		return nil, nil
+	}
+
+	return r.NewStringReplacer(string(old), string(new)).(r.BytesReplacer), nil
+}
+
+func newSyntaxScope(options Options) (*syntax.Scope, error) {
+
+	if options.SourceScope == "" {
+		return nil, nil
+	}
+
+	return syntax.NewScope(options.SourceScope)
+}
+
+func newReplacer(options Options, syntaxScope *syntax.Scope, format format.Format, lengthChecker *r.LengthChecker, recorder *r.MatchRecorder) (*contentReplacer, error) {
+
+	replacer, err := newBaseReplacer(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if recorder != nil {
+		replacer = r.NewRecordingReplacer(replacer, recorder)
+	}
+
+	if lengthChecker != nil {
+		// マッチ単位で長さを確認するため、最も内側とする
+		replacer = r.NewPreserveLengthReplacer(replacer, lengthChecker)
+	}
+
+	if options.LineMode && options.Operation == "replace" {
+		// 行単位で置換 (置換以外の操作は元々行単位)
+		replacer = r.NewLineReplacer(replacer)
+	}
+
+	// 範囲の指定は内側から順に適用されるので、行範囲が最も外側になるようにする
+	if options.Before != "" {
+		scope, err := r.NewBeforeScope(options.Before, options.Inclusive)
+		if err != nil {
+			return nil, err
+		}
+		replacer = r.NewScopedReplacer(replacer, scope)
+	}
+
+	if options.After != "" {
+		scope, err := r.NewAfterScope(options.After, options.Inclusive)
+		if err != nil {
+			return nil, err
+		}
+		replacer = r.NewScopedReplacer(replacer, scope)
+	}
+
+	if options.BetweenStart != "" {
+		scope, err := r.NewBetweenScope(options.BetweenStart, options.BetweenEnd, options.Inclusive)
+		if err != nil {
+			return nil, err
+		}
+		replacer = r.NewScopedReplacer(replacer, scope)
+	}
+
+	if options.LineStart != 0 || options.LineEnd != 0 {
+		replacer = r.NewScopedReplacer(replacer, r.NewLineRangeScope(options.LineStart, options.LineEnd))
+	}
+
+	if syntaxScope != nil {
+		// コメントや文字列の判定にはファイル全体が必要なので、範囲の指定の中で最も外側とする
+		replacer = r.NewScopedReplacer(replacer, syntaxScope)
+	}
+
+	var split *r.SplitReplacer
+	if format != nil {
+		// 値ごとに置換するが、置換数の制限や条件はファイル全体で判定できるよう、ここまでを値ごとに適用
+		split = r.NewSplitReplacer(replacer, format.Replace)
+		replacer = split
+	}
+
+	if options.Occurrence != 0 || options.MaxCount != 0 || options.MaxTotal != 0 {
+		// 置換数の制限はファイル全体でのマッチ順で判定するため、最も外側とする
+		replacer = r.NewLimitReplacer(replacer, options.Occurrence, options.MaxCount, options.MaxTotal)
+	}
+
+	// 適用するかどうかの条件はファイル全体で判定
+	if options.If != "" {
+		matcher, err := r.NewRegexpMatcher(options.If)
+		if err != nil {
+			return nil, err
+		}
+		replacer = r.NewIfReplacer(replacer, matcher)
+	}
+
+	if options.Unless != "" {
+		matcher, err := r.NewRegexpMatcher(options.Unless)
+		if err != nil {
+			return nil, err
+		}
+		replacer = r.NewUnlessReplacer(replacer, matcher)
+	}
+
+	return &contentReplacer{replacer: replacer, split: split}, nil
+}
+
+func newBaseReplacer(options Options) (r.Replacer, error) {
+
+	if options.BinaryPattern != "" {
+		return r.NewBinaryReplacer(options.BinaryPattern, options.Replacement)
+	}
+
+	if options.Operation != "replace" {
+		return newOperationReplacer(options)
+	}
+
+	if options.TargetRegex != "" {
+		replacer, err := r.NewRegexpReplacer(regexFlags(options)+options.TargetRegex, options.Replacement)
+		if err != nil {
+			return nil, err
+		}
+		return replacer, nil
+	}
+
+	return r.NewStringReplacer(options.TargetString, options.Replacement), nil
+}
+
+func newOperationReplacer(options Options) (r.Replacer, error) {
+
+	matcher, err := newMatcher(options)
+	if err != nil {
+		return nil, err
+	}
+
+	switch options.Operation {
+	case "delete-line", "ensure-absent":
+		return r.NewDeleteLineReplacer(matcher), nil
+	case "insert-before":
+		return r.NewInsertBeforeReplacer(matcher, options.Replacement), nil
+	case "insert-after":
+		return r.NewInsertAfterReplacer(matcher, options.Replacement), nil
+	case "ensure-present":
+		return r.NewEnsureLineReplacer(matcher, options.Replacement), nil
+	default:
+		return nil, fmt.Errorf("unknown operation \"%s\"", options.Operation)
+	}
+}
+
+func newMatcher(options Options) (r.Matcher, error) {
+
+	if options.TargetRegex != "" {
+		return r.NewRegexpMatcher(regexFlags(options) + options.TargetRegex)
+	}
+
+	return r.NewStringMatcher(options.TargetString), nil
+}
+
+func regexFlags(options Options) string {
+
+	flags := ""
+	if options.Multiline {
+		flags += "m"
+	}
+	if options.DotAll {
+		flags += "s"
+	}
+
+	if flags == "" {
+		return ""
+	}
+
+	return "(?" + flags + ")"
+}
+
+func newEncoder(options Options) (encoder.Encoder, error) {
+
+	if options.BinaryPattern != "" || options.PatchAt != "" || options.PatchFile != "" {
+		// バイナリのパターンやオフセットはバイト単位なので、バイト列のまま扱う
+		return &encoder.RawEncoder{}, nil
+	}
+
+	return encoder.NewEncoder(options.Charset)
+}
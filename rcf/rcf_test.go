@@ -0,0 +1,639 @@
+package rcf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestRun(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "abc\nxyz\n")
+	output := filepath.Join(d, "output.txt")
+
+	// ACT
+	err := Run(context.Background(), input, output, Options{
+		TargetString: "b",
+		Replacement:  "B",
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, "aBc\nxyz\n", readString(t, output))
+}
+
+func TestRun_InvalidOptions(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "abc.txt", "abc\n")
+
+	// ACT
+	err := Run(context.Background(), input, input, Options{
+		TargetString: "abc",
+		Replacement:  "x",
+		HexDump:      true,
+		RenamePaths:  true,
+	})
+
+	// ASSERT
+	require.Error(t, err)
+	assert.Equal(t, "--hex-dump cannot be used with line, scope, format, patch, length and rename options", err.Error())
+
+	// 名前も内容も変更されない
+	assert.Equal(t, "abc\n", readString(t, input))
+	assert.NoFileExists(t, filepath.Join(d, "x.txt"))
+}
+
+func TestOptions_Validate(t *testing.T) {
+
+	{
+		err := Options{TargetString: "a", BetweenStart: "BEGIN"}.withDefaults().validate()
+		require.Error(t, err)
+		assert.Equal(t, "--between-start and --between-end must be specified together", err.Error())
+	}
+	{
+		err := Options{PatchAt: "0x10"}.withDefaults().validate()
+		require.Error(t, err)
+		assert.Equal(t, "--patch-at and --patch-bytes must be specified together", err.Error())
+	}
+	{
+		err := Options{TargetString: "a", PreserveLength: true, Operation: "delete-line"}.withDefaults().validate()
+		require.Error(t, err)
+		assert.Equal(t, "--preserve-length can only be used with --operation replace", err.Error())
+	}
+	{
+		err := Options{TargetString: "a", DryRun: true}.withDefaults().validate()
+		require.Error(t, err)
+		assert.Equal(t, "--dry-run requires --rename-paths, --paths-only or --go-rename", err.Error())
+	}
+	{
+		err := Options{TargetString: "a", HexDump: true, LineStart: 1}.withDefaults().validate()
+		require.Error(t, err)
+		assert.Equal(t, "--hex-dump cannot be used with line, scope, format, patch, length and rename options", err.Error())
+	}
+	{
+		err := Options{TargetString: "a", Archive: true, Mirror: true}.withDefaults().validate()
+		require.Error(t, err)
+		assert.Equal(t, "--archive cannot be used with rename and mirror options", err.Error())
+	}
+	{
+		err := Options{TargetRegex: "a", GoRename: true}.withDefaults().validate()
+		require.Error(t, err)
+		assert.Equal(t, "--go-rename cannot be used with content replacement options", err.Error())
+	}
+	{
+		err := Options{GoRename: true}.withDefaults().validate()
+		require.Error(t, err)
+		assert.Equal(t, "--go-rename requires --string", err.Error())
+	}
+}
+
+func TestReplaceReader_InvalidOptions(t *testing.T) {
+
+	// ACT
+	err := ReplaceReader(context.Background(), strings.NewReader("abc"), &bytes.Buffer{}, Options{
+		TargetString: "a",
+		Pad:          "00",
+	})
+
+	// ASSERT
+	require.Error(t, err)
+	assert.Equal(t, "--pad requires --preserve-length", err.Error())
+}
+
+func TestRun_FormatLimitAndGuard(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input1 := createFileWriteString(t, d, "1.json", `{"a":"x","b":"x"}`)
+	input2 := createFileWriteString(t, d, "2.json", `{"a":"x","b":"x"}`)
+
+	// ACT
+	// 置換数の制限や条件は値ごとではなくファイル全体で判定
+	err1 := Run(context.Background(), input1, input1, Options{
+		TargetString: "x",
+		Replacement:  "y",
+		Format:       "json",
+		Occurrence:   1,
+	})
+	err2 := Run(context.Background(), input2, input2, Options{
+		TargetString: "x",
+		Replacement:  "y",
+		Format:       "json",
+		If:           `"b"`,
+	})
+
+	// ASSERT
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	assert.Equal(t, `{"a":"y","b":"x"}`, readString(t, input1))
+	assert.Equal(t, `{"a":"y","b":"y"}`, readString(t, input2))
+}
+
+func TestRun_HTMLShiftJIS(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	contents, err := japanese.ShiftJIS.NewEncoder().String(`<html><head><meta charset="Shift_JIS"></head><body><p>&copy; 2020 日本</p></body></html>`)
+	require.NoError(t, err)
+	input := createFileWriteString(t, d, "index.html", contents)
+
+	// ACT
+	err = Run(context.Background(), input, input, Options{
+		TargetString: "2020",
+		Replacement:  "2021",
+		Format:       "html",
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	// Shift_JISで表せない文字も、文字参照のままなので出力できる
+	result, err := japanese.ShiftJIS.NewDecoder().String(readString(t, input))
+	require.NoError(t, err)
+	assert.Equal(t, `<html><head><meta charset="Shift_JIS"></head><body><p>&copy; 2021 日本</p></body></html>`, result)
+}
+
+func TestRun_FormatError(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.json", `{"a":`)
+
+	// ACT
+	err := Run(context.Background(), input, input, Options{
+		TargetString: "x",
+		Replacement:  "y",
+		Format:       "json",
+		Occurrence:   -1,
+	})
+
+	// ASSERT
+	fileErr := &FileError{}
+	require.ErrorAs(t, err, &fileErr)
+	assert.Equal(t, input, fileErr.Path)
+}
+
+func TestRun_OnFile(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	inputDir := filepath.Join(d, "input")
+	require.NoError(t, os.Mkdir(inputDir, 0777))
+	input1 := createFileWriteString(t, inputDir, "1.txt", "abc")
+	input2 := createFileWriteString(t, inputDir, "2.txt", "xyz")
+	outputDir := filepath.Join(d, "output")
+
+	events := []FileEvent{}
+
+	// ACT
+	err := Run(context.Background(), inputDir, outputDir, Options{
+		TargetString: "a",
+		Replacement:  "A",
+		FilesWith:    "a",
+		Mirror:       true,
+		OnFile: func(event FileEvent) {
+			events = append(events, event)
+		},
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, []FileEvent{
+		{InputPath: input1, OutputPath: filepath.Join(outputDir, "1.txt"), Action: FileReplaced},
+		{InputPath: input2, OutputPath: filepath.Join(outputDir, "2.txt"), Action: FileCopied},
+	}, events)
+	assert.Equal(t, "Abc", readString(t, filepath.Join(outputDir, "1.txt")))
+	assert.Equal(t, "xyz", readString(t, filepath.Join(outputDir, "2.txt")))
+}
+
+func TestRun_Canceled(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	inputDir := filepath.Join(d, "input")
+	require.NoError(t, os.Mkdir(inputDir, 0777))
+	createFileWriteString(t, inputDir, "1.txt", "abc")
+	outputDir := filepath.Join(d, "output")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// ACT
+	err := Run(ctx, inputDir, outputDir, Options{
+		TargetString: "a",
+		Replacement:  "A",
+	})
+
+	// ASSERT
+	require.ErrorIs(t, err, context.Canceled)
+	assert.NoFileExists(t, filepath.Join(outputDir, "1.txt"))
+}
+
+func TestRun_LengthError(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{0x00, 0x01, 0x02})
+	output := filepath.Join(d, "output.bin")
+
+	var stderr bytes.Buffer
+
+	// ACT
+	err := Run(context.Background(), input, output, Options{
+		BinaryPattern:  "01",
+		Replacement:    "03 04",
+		PreserveLength: true,
+		Stderr:         &stderr,
+	})
+
+	// ASSERT
+	var lengthError *LengthError
+	require.True(t, errors.As(err, &lengthError))
+	assert.Equal(t, input, lengthError.Path)
+	assert.Len(t, lengthError.Violations, 1)
+	assert.Equal(t, input+": offset 0x1: 1 bytes -> 2 bytes\n", stderr.String())
+}
+
+func TestRun_FileError(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteBytes(t, d, "input.bin", []byte{0x00, 0x01})
+	output := filepath.Join(d, "output.bin")
+
+	// ACT
+	err := Run(context.Background(), input, output, Options{
+		PatchAt:     "0",
+		PatchExpect: "FF",
+		PatchBytes:  "00",
+	})
+
+	// ASSERT
+	var fileError *FileError
+	require.True(t, errors.As(err, &fileError))
+	assert.Equal(t, input, fileError.Path)
+	assert.Equal(t, "offset 0x0: expected FF but found 00", fileError.Err.Error())
+}
+
+func TestRun_FileError_Charset(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	input := createFileWriteString(t, d, "input.txt", "abc")
+	output := filepath.Join(d, "output.txt")
+
+	{
+		// ACT
+		err := Run(context.Background(), input, output, Options{
+			TargetString: "b",
+			Replacement:  "あ",
+			Charset:      "latin1",
+		})
+
+		// ASSERT
+		var fileError *FileError
+		require.True(t, errors.As(err, &fileError))
+		assert.Equal(t, input, fileError.Path)
+		assert.Equal(t, "character 'あ' cannot be represented in latin1", fileError.Err.Error())
+	}
+	{
+		// ACT
+		err := Run(context.Background(), input, output, Options{
+			TargetString: "W",
+			Replacement:  "!",
+			Charset:      "base64",
+		})
+
+		// ASSERT
+		var fileError *FileError
+		require.True(t, errors.As(err, &fileError))
+		assert.Equal(t, input, fileError.Path)
+	}
+}
+
+func TestRun_NameConflictError(t *testing.T) {
+
+	// ARRANGE
+	d := createTempDir(t)
+	defer os.RemoveAll(d)
+
+	createFileWriteString(t, d, "a1.txt", "")
+	createFileWriteString(t, d, "a2.txt", "")
+
+	// ACT
+	err := Run(context.Background(), d, d, Options{
+		TargetRegex: "[0-9]",
+		RenamePaths: true,
+	})
+
+	// ASSERT
+	var conflictError *NameConflictError
+	require.True(t, errors.As(err, &conflictError))
+	assert.Equal(t, "a.txt", conflictError.Name)
+}
+
+func TestReplaceFS(t *testing.T) {
+
+	// ARRANGE
+	in := NewMemFS()
+	require.NoError(t, in.Mkdir("sub", 0755))
+	require.NoError(t, in.WriteFile("sub/1.txt", []byte("abc"), 0644))
+	require.NoError(t, in.WriteFile("2.txt", []byte("xyz"), 0600))
+	require.NoError(t, in.Symlink("2.txt", "link"))
+
+	out := NewMemFS()
+
+	// ACT
+	err := ReplaceFS(context.Background(), in, out, Options{
+		TargetString: "a",
+		Replacement:  "A",
+		FilesWith:    "a",
+		Recursive:    true,
+		Mirror:       true,
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	data, err := fs.ReadFile(out, "sub/1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "Abc", string(data))
+
+	data, err = fs.ReadFile(out, "2.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "xyz", string(data))
+
+	info, err := out.Lstat("2.txt")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0600), info.Mode())
+
+	target, err := out.ReadLink("link")
+	require.NoError(t, err)
+	assert.Equal(t, "2.txt", target)
+}
+
+func TestReplaceFS_RenamePaths_Symlink(t *testing.T) {
+
+	// ARRANGE
+	in := NewMemFS()
+	require.NoError(t, in.Mkdir("old", 0755))
+	require.NoError(t, in.WriteFile("old/old.txt", []byte("x"), 0644))
+	require.NoError(t, in.WriteFile("old.txt", []byte("x"), 0644))
+	require.NoError(t, in.Symlink("old/old.txt", "link-old"))
+	require.NoError(t, in.Symlink("../old.txt", "old/link"))
+	require.NoError(t, in.Symlink("../../old.txt", "outside"))
+	require.NoError(t, in.Symlink("/tmp/old.txt", "absolute"))
+
+	out := NewMemFS()
+
+	// ACT
+	err := ReplaceFS(context.Background(), in, out, Options{
+		TargetString: "old",
+		Replacement:  "new",
+		RenamePaths:  true,
+		Recursive:    true,
+		Mirror:       true,
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	// リンク先も置換後の名前に合わせる
+	target, err := out.ReadLink("link-new")
+	require.NoError(t, err)
+	assert.Equal(t, "new/new.txt", target)
+
+	target, err = out.ReadLink("new/link")
+	require.NoError(t, err)
+	assert.Equal(t, "../new.txt", target)
+
+	// 入力の外や絶対パスはそのまま
+	target, err = out.ReadLink("outside")
+	require.NoError(t, err)
+	assert.Equal(t, "../../old.txt", target)
+
+	target, err = out.ReadLink("absolute")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/old.txt", target)
+}
+
+func TestReplaceFS_RenamePaths_Symlink_NotRecursive(t *testing.T) {
+
+	// ARRANGE
+	m := NewMemFS()
+	require.NoError(t, m.Mkdir("old", 0755))
+	require.NoError(t, m.WriteFile("old/old.txt", []byte("x"), 0644))
+	require.NoError(t, m.WriteFile("old.txt", []byte("x"), 0644))
+	require.NoError(t, m.Symlink("old.txt", "link1"))
+	require.NoError(t, m.Symlink("old/old.txt", "link2"))
+	require.NoError(t, m.Symlink("old", "link3"))
+
+	// ACT
+	err := ReplaceFS(context.Background(), m, nil, Options{
+		TargetString: "old",
+		Replacement:  "new",
+		RenamePaths:  true,
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	// たどらないディレクトリとその中のものは名前を変えない
+	target, err := m.ReadLink("link1")
+	require.NoError(t, err)
+	assert.Equal(t, "new.txt", target)
+
+	target, err = m.ReadLink("link2")
+	require.NoError(t, err)
+	assert.Equal(t, "old/old.txt", target)
+
+	target, err = m.ReadLink("link3")
+	require.NoError(t, err)
+	assert.Equal(t, "old", target)
+
+	data, err := fs.ReadFile(m, "new.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "x", string(data))
+}
+
+func TestReplaceFS_Overwrite(t *testing.T) {
+
+	// ARRANGE
+	m := NewMemFS()
+	require.NoError(t, m.WriteFile("a.txt", []byte("a"), 0644))
+	require.NoError(t, m.WriteFile("b.txt", []byte("b"), 0644))
+
+	// ACT
+	err := ReplaceFS(context.Background(), m, nil, Options{
+		TargetString: "a",
+		Replacement:  "x",
+		RenamePaths:  true,
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+
+	entries, err := fs.ReadDir(m, ".")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "b.txt", entries[0].Name())
+	assert.Equal(t, "x.txt", entries[1].Name())
+
+	data, err := fs.ReadFile(m, "x.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "x", string(data))
+}
+
+func TestReplaceFS_NotWritable(t *testing.T) {
+
+	// ACT
+	err := ReplaceFS(context.Background(), fstest.MapFS{}, nil, Options{
+		TargetString: "a",
+	})
+
+	// ASSERT
+	require.Error(t, err)
+	assert.Equal(t, "input fs.FS is not writable", err.Error())
+}
+
+func TestReplaceReader(t *testing.T) {
+
+	// ARRANGE
+	in := strings.NewReader("name,url\na,http://example.com\n")
+	var out bytes.Buffer
+
+	// ACT
+	err := ReplaceReader(context.Background(), in, &out, Options{
+		TargetString: "http:",
+		Replacement:  "https:",
+		Format:       "csv",
+		Columns:      []string{"url"},
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, "name,url\na,https://example.com\n", out.String())
+}
+
+func TestReplaceReader_Skipped(t *testing.T) {
+
+	// ARRANGE
+	in := strings.NewReader("abc")
+	var out bytes.Buffer
+
+	// ACT
+	err := ReplaceReader(context.Background(), in, &out, Options{
+		TargetString: "a",
+		Replacement:  "A",
+		FilesWith:    "x",
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, "abc", out.String())
+}
+
+func TestReplaceReader_HexDump(t *testing.T) {
+
+	// ARRANGE
+	in := bytes.NewReader([]byte("abc"))
+	var out bytes.Buffer
+	var stdout bytes.Buffer
+
+	// ACT
+	err := ReplaceReader(context.Background(), in, &out, Options{
+		TargetString: "b",
+		Replacement:  "B",
+		HexDump:      true,
+		DumpContext:  16,
+		Stdout:       &stdout,
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, "", out.String())
+	assert.Equal(t,
+		"-: offset 0x1: 1 bytes -> 1 bytes\n"+
+			"- 00000000: 6162 63                                  abc\n"+
+			"+ 00000000: 6142 63                                  aBc\n",
+		stdout.String())
+}
+
+func createTempDir(t *testing.T) string {
+
+	tempDir, err := os.MkdirTemp("", "rcf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tempDir
+}
+
+func createFileWriteBytes(t *testing.T, dir string, name string, content []byte) string {
+
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = file.Write(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return file.Name()
+}
+
+func createFileWriteString(t *testing.T, dir string, name string, content string) string {
+
+	return createFileWriteBytes(t, dir, name, []byte(content))
+}
+
+func readString(t *testing.T, name string) string {
+
+	bo, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(bo)
+}
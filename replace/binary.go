@@ -0,0 +1,280 @@
+package replace
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 1バイトにマッチする範囲
+type byteRange struct {
+	min byte
+	max byte
+}
+
+// 置換後の1バイト (keepの場合はマッチした位置のバイトをそのまま使う)
+type replacementByte struct {
+	value byte
+	keep  bool
+}
+
+// 文字列をバイト列として扱い、バイト単位でマッチさせる
+type binaryReplacer struct {
+	pattern     []byteRange
+	replacement []replacementByte
+}
+
+// パターンは 4F 4B (16進数)、?? (任意の1バイト)、[00-1F] (範囲)、"PK" (ASCII文字列) の組み合わせ
+// 置換後の値も同じ形式で、?? はマッチしたバイトをそのまま残す
+func NewBinaryReplacer(patternStr string, replacementStr string) (Replacer, error) {
+
+	pattern, err := parseBinaryPattern(patternStr, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(pattern) == 0 {
+		return nil, fmt.Errorf("invalid binary pattern \"%s\": empty", patternStr)
+	}
+
+	replacementPattern, err := parseBinaryPattern(replacementStr, false)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement := []replacementByte{}
+	for i, b := range replacementPattern {
+		if b.min == b.max {
+			replacement = append(replacement, replacementByte{value: b.min})
+			continue
+		}
+		// 任意のバイト
+		if i >= len(pattern) {
+			return nil, fmt.Errorf("invalid binary pattern \"%s\": ?? is out of the match", replacementStr)
+		}
+		replacement = append(replacement, replacementByte{keep: true})
+	}
+
+	return &binaryReplacer{
+		pattern:     pattern,
+		replacement: replacement,
+	}, nil
+}
+
+func parseBinaryPattern(str string, allowRange bool) ([]byteRange, error) {
+
+	pattern := []byteRange{}
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid binary pattern \"%s\": %s", str, reason)
+	}
+
+	s := str
+	for len(s) > 0 {
+		switch {
+		case s[0] == ' ' || s[0] == '\t' || s[0] == ',':
+			s = s[1:]
+
+		case s[0] == '"':
+			end := -1
+			literal := []byte{}
+			for i := 1; i < len(s); i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+					literal = append(literal, s[i])
+					continue
+				}
+				if s[i] == '"' {
+					end = i
+					break
+				}
+				literal = append(literal, s[i])
+			}
+			if end == -1 {
+				return nil, invalid("literal is not closed")
+			}
+			for _, b := range literal {
+				pattern = append(pattern, byteRange{min: b, max: b})
+			}
+			s = s[end+1:]
+
+		case strings.HasPrefix(s, "??"):
+			pattern = append(pattern, byteRange{min: 0x00, max: 0xFF})
+			s = s[2:]
+
+		case s[0] == '[':
+			if !allowRange {
+				return nil, invalid("range is not allowed")
+			}
+			end := strings.IndexByte(s, ']')
+			if end == -1 {
+				return nil, invalid("range is not closed")
+			}
+			bounds := strings.Split(s[1:end], "-")
+			if len(bounds) != 2 {
+				return nil, invalid("range must be [HH-HH]")
+			}
+			min, err := parseHexByte(strings.TrimSpace(bounds[0]))
+			if err != nil {
+				return nil, invalid(err.Error())
+			}
+			max, err := parseHexByte(strings.TrimSpace(bounds[1]))
+			if err != nil {
+				return nil, invalid(err.Error())
+			}
+			if min > max {
+				return nil, invalid("range is reversed")
+			}
+			pattern = append(pattern, byteRange{min: min, max: max})
+			s = s[end+1:]
+
+		default:
+			// -c binary と同じ x4F の形式も受け付ける
+			if s[0] == 'x' {
+				s = s[1:]
+			}
+			if len(s) < 2 {
+				return nil, invalid("hex must be 2 digits")
+			}
+			b, err := parseHexByte(s[:2])
+			if err != nil {
+				return nil, invalid(err.Error())
+			}
+			pattern = append(pattern, byteRange{min: b, max: b})
+			s = s[2:]
+		}
+	}
+
+	return pattern, nil
+}
+
+// 任意のバイトや範囲を含まない、バイト列のみのパターン
+func parseBinaryBytes(str string) ([]byte, error) {
+
+	pattern, err := parseBinaryPattern(str, false)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes := []byte{}
+	for _, b := range pattern {
+		if b.min != b.max {
+			return nil, fmt.Errorf("invalid binary pattern \"%s\": ?? is not allowed", str)
+		}
+		bytes = append(bytes, b.min)
+	}
+
+	return bytes, nil
+}
+
+func parseHexByte(h string) (byte, error) {
+
+	if len(h) != 2 {
+		return 0, fmt.Errorf("illegal hex \"%s\"", h)
+	}
+
+	value := 0
+	for i := 0; i < 2; i++ {
+		c := h[i]
+		switch {
+		case '0' <= c && c <= '9':
+			value = value*16 + int(c-'0')
+		case 'a' <= c && c <= 'f':
+			value = value*16 + int(c-'a'+10)
+		case 'A' <= c && c <= 'F':
+			value = value*16 + int(c-'A'+10)
+		default:
+			return 0, fmt.Errorf("illegal hex \"%s\"", h)
+		}
+	}
+
+	return byte(value), nil
+}
+
+func (r *binaryReplacer) Replace(s string) string {
+
+	return r.replaceSelected(s, selectAll)
+}
+
+func (r *binaryReplacer) ReplaceBytes(b []byte) []byte {
+
+	result := make([]byte, 0, len(b))
+	last := 0
+	for pos := 0; pos+len(r.pattern) <= len(b); {
+		if !r.matchBytesAt(b, pos) {
+			pos++
+			continue
+		}
+
+		result = append(result, b[last:pos]...)
+		for i, rb := range r.replacement {
+			if rb.keep {
+				result = append(result, b[pos+i])
+			} else {
+				result = append(result, rb.value)
+			}
+		}
+		pos += len(r.pattern)
+		last = pos
+	}
+
+	return append(result, b[last:]...)
+}
+
+func (r *binaryReplacer) replaceSelected(s string, sel selector) string {
+
+	return replaceMatches(s, r.findMatches(s), sel)
+}
+
+func (r *binaryReplacer) findMatches(s string) []match {
+
+	matches := []match{}
+	for _, index := range r.indexAll(s) {
+		end := index + len(r.pattern)
+		replacement := make([]byte, len(r.replacement))
+		for i, b := range r.replacement {
+			if b.keep {
+				replacement[i] = s[index+i]
+			} else {
+				replacement[i] = b.value
+			}
+		}
+		matches = append(matches, match{start: index, end: end, replacement: string(replacement)})
+	}
+
+	return matches
+}
+
+func (r *binaryReplacer) indexAll(s string) []int {
+
+	indexes := []int{}
+	for pos := 0; pos+len(r.pattern) <= len(s); {
+		if r.matchAt(s, pos) {
+			indexes = append(indexes, pos)
+			pos += len(r.pattern)
+		} else {
+			pos++
+		}
+	}
+
+	return indexes
+}
+
+func (r *binaryReplacer) matchAt(s string, pos int) bool {
+
+	for i, b := range r.pattern {
+		if s[pos+i] < b.min || s[pos+i] > b.max {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *binaryReplacer) matchBytesAt(b []byte, pos int) bool {
+
+	for i, p := range r.pattern {
+		if b[pos+i] < p.min || b[pos+i] > p.max {
+			return false
+		}
+	}
+
+	return true
+}
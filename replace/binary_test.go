@@ -0,0 +1,111 @@
+package replace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryReplacer(t *testing.T) {
+
+	replacer, err := NewBinaryReplacer(`"PK" 03 04`, "50 4b 05 06")
+	require.NoError(t, err)
+
+	{
+		result := replacer.Replace("\x00PK\x03\x04\xFFPK\x03\x05")
+		assert.Equal(t, "\x00PK\x05\x06\xFFPK\x03\x05", result)
+	}
+	{
+		result := replacer.Replace("PK\x03")
+		assert.Equal(t, "PK\x03", result)
+	}
+}
+
+func TestBinaryReplacer_Wildcard(t *testing.T) {
+
+	// ?? は任意のバイトにマッチし、置換後の ?? はマッチしたバイトを残す
+	replacer, err := NewBinaryReplacer("FF ?? [00-0F]", "?? ?? 00")
+	require.NoError(t, err)
+
+	result := replacer.Replace("\xFF\xAB\x05\xFF\xAB\x10\xFF\xFF\x0F")
+	assert.Equal(t, "\xFF\xAB\x00\xFF\xAB\x10\xFF\xFF\x00", result)
+}
+
+func TestBinaryReplacer_Aligned(t *testing.T) {
+
+	// -c binary のように、ヘキサ文字の途中にマッチすることは無い
+	replacer, err := NewBinaryReplacer("x4Fx4B", "")
+	require.NoError(t, err)
+
+	result := replacer.Replace("\x04\xF4\x4F\x4B")
+	assert.Equal(t, "\x04\xF4", result)
+}
+
+func TestBinaryReplacer_Escape(t *testing.T) {
+
+	replacer, err := NewBinaryReplacer(`"a\"b\\"`, `"x",00`)
+	require.NoError(t, err)
+
+	result := replacer.Replace(`a"b\a"b\`)
+	assert.Equal(t, "x\x00x\x00", result)
+}
+
+func TestBinaryReplacer_Selected(t *testing.T) {
+
+	binaryReplacer, err := NewBinaryReplacer("00", "FF")
+	require.NoError(t, err)
+
+	replacer := NewLimitReplacer(binaryReplacer, 2, 0, 0)
+
+	result := replacer.Replace("\x00\x00\x00")
+	assert.Equal(t, "\x00\xFF\x00", result)
+}
+
+func TestBinaryReplacer_ReplaceBytes(t *testing.T) {
+
+	replacer, err := NewBinaryReplacer("FF ?? [00-0F]", `?? "ab" 00`)
+	require.NoError(t, err)
+
+	result := replacer.(BytesReplacer).ReplaceBytes([]byte{0xFF, 0xAB, 0x05, 0xFF, 0xAB, 0x10, 0xFF, 0xFF, 0x0F, 0xFF})
+	assert.Equal(t, []byte{0xFF, 'a', 'b', 0x00, 0xFF, 0xAB, 0x10, 0xFF, 'a', 'b', 0x00, 0xFF}, result)
+}
+
+func TestBinaryReplacer_Invalid(t *testing.T) {
+
+	{
+		_, err := NewBinaryReplacer("4", "")
+		require.Error(t, err)
+		assert.Equal(t, "invalid binary pattern \"4\": hex must be 2 digits", err.Error())
+	}
+	{
+		_, err := NewBinaryReplacer("GG", "")
+		require.Error(t, err)
+		assert.Equal(t, "invalid binary pattern \"GG\": illegal hex \"GG\"", err.Error())
+	}
+	{
+		_, err := NewBinaryReplacer(`"ab`, "")
+		require.Error(t, err)
+		assert.Equal(t, "invalid binary pattern \"\"ab\": literal is not closed", err.Error())
+	}
+	{
+		_, err := NewBinaryReplacer("[10-00]", "")
+		require.Error(t, err)
+		assert.Equal(t, "invalid binary pattern \"[10-00]\": range is reversed", err.Error())
+	}
+	{
+		_, err := NewBinaryReplacer("00", "[00-10]")
+		require.Error(t, err)
+		assert.Equal(t, "invalid binary pattern \"[00-10]\": range is not allowed", err.Error())
+	}
+	{
+		_, err := NewBinaryReplacer("00", "?? ??")
+		require.Error(t, err)
+		assert.Equal(t, "invalid binary pattern \"?? ??\": ?? is out of the match", err.Error())
+	}
+	{
+		_, err := NewBinaryReplacer("", "00")
+		require.Error(t, err)
+		assert.Equal(t, "invalid binary pattern \"\": empty", err.Error())
+	}
+}
@@ -0,0 +1,36 @@
+package replace
+
+type guardReplacer struct {
+	replacer Replacer
+	matcher  Matcher
+	want     bool
+}
+
+// 対象の文字列が条件にマッチする場合のみ置換
+func NewIfReplacer(replacer Replacer, matcher Matcher) Replacer {
+
+	return &guardReplacer{
+		replacer: replacer,
+		matcher:  matcher,
+		want:     true,
+	}
+}
+
+// 対象の文字列が条件にマッチしない場合のみ置換
+func NewUnlessReplacer(replacer Replacer, matcher Matcher) Replacer {
+
+	return &guardReplacer{
+		replacer: replacer,
+		matcher:  matcher,
+		want:     false,
+	}
+}
+
+func (r *guardReplacer) Replace(s string) string {
+
+	if r.matcher.Match(s) != r.want {
+		return s
+	}
+
+	return r.replacer.Replace(s)
+}
@@ -0,0 +1,46 @@
+package replace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfReplacer(t *testing.T) {
+
+	replacer := NewIfReplacer(NewStringReplacer("a", "x"), NewStringMatcher("#"))
+
+	{
+		result := replacer.Replace("a#a")
+		assert.Equal(t, "x#x", result)
+	}
+	{
+		result := replacer.Replace("aa")
+		assert.Equal(t, "aa", result)
+	}
+}
+
+func TestUnlessReplacer(t *testing.T) {
+
+	matcher, err := NewRegexpMatcher("(?m)^prefix-")
+	if err != nil {
+		t.Fatal("NewRegexpMatcher failed", err)
+	}
+
+	replacer, err := NewRegexpReplacer("^", "prefix-")
+	if err != nil {
+		t.Fatal("NewRegexpReplacer failed", err)
+	}
+
+	replacer = NewUnlessReplacer(replacer, matcher)
+
+	{
+		result := replacer.Replace("abc")
+		assert.Equal(t, "prefix-abc", result)
+	}
+	{
+		// 適用済みなので変わらない
+		result := replacer.Replace("prefix-abc")
+		assert.Equal(t, "prefix-abc", result)
+	}
+}
@@ -0,0 +1,105 @@
+package replace
+
+import "strings"
+
+// 置換前後で長さが変わった箇所
+type LengthViolation struct {
+	Offset            int // 置換前の文字列全体でのインデックス (範囲を指定した場合も全体での位置)
+	MatchLength       int
+	ReplacementLength int
+}
+
+// 置換前後で長さ(バイト数)が変わらないかを確認
+// 長さの数え方は文字列の表現(-c binary など)によって異なるので、lengthで指定
+type LengthChecker struct {
+	length     func(string) int
+	pad        string
+	violations []LengthViolation
+}
+
+// padを指定した場合、短くなる置換はpadで埋めて同じ長さにする
+func NewLengthChecker(length func(string) int, pad string) *LengthChecker {
+
+	return &LengthChecker{
+		length: length,
+		pad:    pad,
+	}
+}
+
+// 前回のReplace以降に、長さが変わるため置換しなかった箇所
+func (c *LengthChecker) Violations() []LengthViolation {
+
+	return c.violations
+}
+
+func (c *LengthChecker) Reset() {
+
+	c.violations = nil
+}
+
+type preserveLengthReplacer struct {
+	replacer Replacer
+	checker  *LengthChecker
+}
+
+// 長さが変わる置換は行わず、LengthCheckerに記録する
+func NewPreserveLengthReplacer(replacer Replacer, checker *LengthChecker) Replacer {
+
+	return &preserveLengthReplacer{
+		replacer: replacer,
+		checker:  checker,
+	}
+}
+
+func (r *preserveLengthReplacer) Replace(s string) string {
+
+	return r.replaceAt(s, 0, selectAll)
+}
+
+func (r *preserveLengthReplacer) replaceSelected(s string, sel selector) string {
+
+	return r.replaceAt(s, 0, sel)
+}
+
+func (r *preserveLengthReplacer) replaceAt(s string, offset int, sel selector) string {
+
+	if sel == nil {
+		sel = selectAll
+	}
+
+	finder, ok := r.replacer.(matchFinder)
+	if !ok {
+		// マッチ単位で扱えないもの(行の削除など)は確認できない
+		return replaceSelected(r.replacer, s, sel)
+	}
+
+	matches := finder.findMatches(s)
+	violations := make([]*LengthViolation, len(matches))
+	for i, m := range matches {
+		matchLength := r.checker.length(s[m.start:m.end])
+		replacementLength := r.checker.length(m.replacement)
+
+		if replacementLength < matchLength && r.checker.pad != "" {
+			matches[i].replacement += strings.Repeat(r.checker.pad, matchLength-replacementLength)
+		} else if replacementLength != matchLength {
+			violations[i] = &LengthViolation{
+				Offset:            offset + m.start,
+				MatchLength:       matchLength,
+				ReplacementLength: replacementLength,
+			}
+		}
+	}
+
+	index := -1
+	return replaceMatches(s, matches, func() bool {
+		index++
+		if !sel() {
+			return false
+		}
+		if violations[index] != nil {
+			r.checker.violations = append(r.checker.violations, *violations[index])
+			return false
+		}
+		return true
+	})
+}
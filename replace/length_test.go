@@ -0,0 +1,96 @@
+package replace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hexLength(s string) int {
+	// x00 の3文字で1バイト
+	return len(s) / 3
+}
+
+func TestPreserveLengthReplacer(t *testing.T) {
+
+	checker := NewLengthChecker(hexLength, "")
+	replacer := NewPreserveLengthReplacer(NewStringReplacer("x01x02", "x03x04"), checker)
+
+	result := replacer.Replace("x00x01x02x01x02")
+	assert.Equal(t, "x00x03x04x03x04", result)
+	assert.Empty(t, checker.Violations())
+}
+
+func TestPreserveLengthReplacer_Violation(t *testing.T) {
+
+	checker := NewLengthChecker(hexLength, "")
+	replacer := NewPreserveLengthReplacer(NewStringReplacer("x01x02", "x03"), checker)
+
+	// 長さが変わる置換は行わない
+	result := replacer.Replace("x00x01x02x01x02")
+	assert.Equal(t, "x00x01x02x01x02", result)
+	// 位置は文字列でのインデックス、長さはlengthで数えたもの
+	assert.Equal(t, []LengthViolation{
+		{Offset: 3, MatchLength: 2, ReplacementLength: 1},
+		{Offset: 9, MatchLength: 2, ReplacementLength: 1},
+	}, checker.Violations())
+
+	checker.Reset()
+	assert.Empty(t, checker.Violations())
+}
+
+func TestPreserveLengthReplacer_Pad(t *testing.T) {
+
+	checker := NewLengthChecker(hexLength, "x00")
+	replacer := NewPreserveLengthReplacer(NewStringReplacer("x01x02x03", "x04"), checker)
+
+	result := replacer.Replace("x01x02x03xFF")
+	assert.Equal(t, "x04x00x00xFF", result)
+	assert.Empty(t, checker.Violations())
+}
+
+func TestPreserveLengthReplacer_Longer(t *testing.T) {
+
+	checker := NewLengthChecker(func(s string) int { return len(s) }, "\x00")
+
+	binaryReplacer, err := NewBinaryReplacer("01", "02 02")
+	require.NoError(t, err)
+	replacer := NewPreserveLengthReplacer(binaryReplacer, checker)
+
+	// 長くなる場合は埋められない
+	result := replacer.Replace("\x00\x01")
+	assert.Equal(t, "\x00\x01", result)
+	assert.Equal(t, []LengthViolation{{Offset: 1, MatchLength: 1, ReplacementLength: 2}}, checker.Violations())
+}
+
+func TestPreserveLengthReplacer_Selected(t *testing.T) {
+
+	checker := NewLengthChecker(func(s string) int { return len(s) }, "")
+
+	regexpReplacer, err := NewRegexpReplacer("a+", "b")
+	require.NoError(t, err)
+	replacer := NewLimitReplacer(NewPreserveLengthReplacer(regexpReplacer, checker), 2, 0, 0)
+
+	// 対象外のマッチは記録しない
+	result := replacer.Replace("a aa a")
+	assert.Equal(t, "a aa a", result)
+	assert.Equal(t, []LengthViolation{{Offset: 2, MatchLength: 2, ReplacementLength: 1}}, checker.Violations())
+}
+
+func TestPreserveLengthReplacer_Region(t *testing.T) {
+
+	checker := NewLengthChecker(func(s string) int { return len(s) }, "")
+
+	regexpReplacer, err := NewRegexpReplacer("a+", "b")
+	require.NoError(t, err)
+	replacer := NewScopedReplacer(NewLineReplacer(NewPreserveLengthReplacer(regexpReplacer, checker)), NewLineRangeScope(2, 0))
+
+	// 範囲や行の中ではなく、全体での位置
+	result := replacer.Replace("aa\nx aa\ny a aa\n")
+	assert.Equal(t, "aa\nx aa\ny b aa\n", result)
+	assert.Equal(t, []LengthViolation{
+		{Offset: 5, MatchLength: 2, ReplacementLength: 1},
+		{Offset: 12, MatchLength: 2, ReplacementLength: 1},
+	}, checker.Violations())
+}
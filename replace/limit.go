@@ -0,0 +1,133 @@
+package replace
+
+import "strings"
+
+// 置換対象とするマッチかどうかを、マッチ順に判定
+type selector func() bool
+
+func selectAll() bool {
+	return true
+}
+
+// マッチ単位で置換対象を選別できるReplacer
+type selectiveReplacer interface {
+	replaceSelected(string, selector) string
+}
+
+func replaceSelected(replacer Replacer, s string, sel selector) string {
+
+	if selective, ok := replacer.(selectiveReplacer); ok {
+		return selective.replaceSelected(s, sel)
+	}
+
+	// マッチ単位での選別ができないものは全て置換
+	return replacer.Replace(s)
+}
+
+// 全体の中での位置を引き継いで置換できるReplacer (範囲を指定した場合も、全体での位置で記録するため)
+type offsetReplacer interface {
+	replaceAt(s string, offset int, sel selector) string
+}
+
+// offsetは全体の中でのsの位置 (selがnilの場合は選別せずに置換)
+func replaceAt(replacer Replacer, s string, offset int, sel selector) string {
+
+	if r, ok := replacer.(offsetReplacer); ok {
+		return r.replaceAt(s, offset, sel)
+	}
+
+	if sel == nil {
+		return replacer.Replace(s)
+	}
+	return replaceSelected(replacer, s, sel)
+}
+
+// 置換前後の位置と置換後の文字列
+type match struct {
+	start       int
+	end         int
+	replacement string
+}
+
+// マッチごとの置換後の文字列を求められるReplacer
+type matchFinder interface {
+	findMatches(string) []match
+}
+
+func replaceMatches(s string, matches []match, sel selector) string {
+
+	var builder strings.Builder
+	last := 0
+	for _, m := range matches {
+		builder.WriteString(s[last:m.start])
+		if sel() {
+			builder.WriteString(m.replacement)
+		} else {
+			builder.WriteString(s[m.start:m.end])
+		}
+		last = m.end
+	}
+	builder.WriteString(s[last:])
+
+	return builder.String()
+}
+
+type limitReplacer struct {
+	replacer   Replacer
+	occurrence int
+	maxCount   int
+	maxTotal   int
+	total      int
+}
+
+// occurrence: 置換するマッチの順番(1始まり、負数の場合は後ろから)
+// maxCount: 1回のReplaceで置換する最大数
+// maxTotal: 全てのReplaceを通して置換する最大数
+// いずれも0の場合は制限なし
+func NewLimitReplacer(replacer Replacer, occurrence int, maxCount int, maxTotal int) Replacer {
+
+	return &limitReplacer{
+		replacer:   replacer,
+		occurrence: occurrence,
+		maxCount:   maxCount,
+		maxTotal:   maxTotal,
+	}
+}
+
+func (r *limitReplacer) Replace(s string) string {
+
+	occurrence := r.occurrence
+	if occurrence < 0 {
+		// 後ろから数える場合、先にマッチ数を数えておく
+		matches := 0
+		replaceSelected(r.replacer, s, func() bool {
+			matches++
+			return false
+		})
+
+		occurrence = matches + occurrence + 1
+		if occurrence < 1 {
+			return s
+		}
+	}
+
+	index := 0
+	count := 0
+	return replaceSelected(r.replacer, s, func() bool {
+		index++
+
+		if occurrence != 0 && index != occurrence {
+			return false
+		}
+		if r.maxCount != 0 && count >= r.maxCount {
+			return false
+		}
+		if r.maxTotal != 0 && r.total >= r.maxTotal {
+			return false
+		}
+
+		count++
+		r.total++
+		return true
+	})
+}
@@ -0,0 +1,117 @@
+package replace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitReplacer_Occurrence(t *testing.T) {
+
+	replacer := NewLimitReplacer(NewStringReplacer("a", "x"), 2, 0, 0)
+
+	{
+		result := replacer.Replace("aaaa")
+		assert.Equal(t, "axaa", result)
+	}
+	{
+		result := replacer.Replace("a")
+		assert.Equal(t, "a", result)
+	}
+}
+
+func TestLimitReplacer_Occurrence_Last(t *testing.T) {
+
+	replacer, err := NewRegexpReplacer("([0-9])", "<$1>")
+	if err != nil {
+		t.Fatal("NewRegexpReplacer failed", err)
+	}
+
+	{
+		result := NewLimitReplacer(replacer, -1, 0, 0).Replace("1a2a3")
+		assert.Equal(t, "1a2a<3>", result)
+	}
+	{
+		result := NewLimitReplacer(replacer, -3, 0, 0).Replace("1a2a3")
+		assert.Equal(t, "<1>a2a3", result)
+	}
+	{
+		result := NewLimitReplacer(replacer, -4, 0, 0).Replace("1a2a3")
+		assert.Equal(t, "1a2a3", result)
+	}
+}
+
+func TestLimitReplacer_MaxCount(t *testing.T) {
+
+	replacer := NewLimitReplacer(NewStringReplacer("a", "x"), 0, 2, 0)
+
+	{
+		result := replacer.Replace("aaaa")
+		assert.Equal(t, "xxaa", result)
+	}
+	{
+		// 1回のReplaceごとに数える
+		result := replacer.Replace("aaa")
+		assert.Equal(t, "xxa", result)
+	}
+}
+
+func TestLimitReplacer_MaxTotal(t *testing.T) {
+
+	replacer := NewLimitReplacer(NewStringReplacer("a", "x"), 0, 0, 3)
+
+	{
+		result := replacer.Replace("aa")
+		assert.Equal(t, "xx", result)
+	}
+	{
+		// 全体を通して数える
+		result := replacer.Replace("aa")
+		assert.Equal(t, "xa", result)
+	}
+	{
+		result := replacer.Replace("aa")
+		assert.Equal(t, "aa", result)
+	}
+}
+
+func TestLimitReplacer_LineMode(t *testing.T) {
+
+	replacer, err := NewRegexpReplacer("^a", "x")
+	if err != nil {
+		t.Fatal("NewRegexpReplacer failed", err)
+	}
+
+	// 行単位で置換する場合も、全体でのマッチ順となる
+	replacer = NewLimitReplacer(NewLineReplacer(replacer), -1, 0, 0)
+
+	{
+		result := replacer.Replace("a\na\na\n")
+		assert.Equal(t, "a\na\nx\n", result)
+	}
+}
+
+func TestLimitReplacer_Scope(t *testing.T) {
+
+	scope, err := NewAfterScope("-", false)
+	if err != nil {
+		t.Fatal("NewAfterScope failed", err)
+	}
+
+	replacer := NewLimitReplacer(NewScopedReplacer(NewStringReplacer("a", "x"), scope), 1, 0, 0)
+
+	{
+		result := replacer.Replace("aa-aa")
+		assert.Equal(t, "aa-xa", result)
+	}
+}
+
+func TestLimitReplacer_EmptyString(t *testing.T) {
+
+	replacer := NewLimitReplacer(NewStringReplacer("", "-"), 0, 2, 0)
+
+	{
+		result := replacer.Replace("abc")
+		assert.Equal(t, "-a-bc", result)
+	}
+}
@@ -0,0 +1,70 @@
+package replace
+
+import (
+	"strings"
+)
+
+type lineReplacer struct {
+	replacer Replacer
+}
+
+func NewLineReplacer(replacer Replacer) Replacer {
+
+	return &lineReplacer{
+		replacer: replacer,
+	}
+}
+
+func (r *lineReplacer) Replace(s string) string {
+
+	return r.replaceAt(s, 0, nil)
+}
+
+func (r *lineReplacer) replaceSelected(s string, sel selector) string {
+
+	return r.replaceAt(s, 0, sel)
+}
+
+func (r *lineReplacer) replaceAt(s string, offset int, sel selector) string {
+
+	var builder strings.Builder
+	for _, line := range splitLines(s) {
+		// 改行コードは置換対象外とし、行の内容だけを置換
+		content, terminator := cutTerminator(line)
+		builder.WriteString(replaceAt(r.replacer, content, offset, sel))
+		builder.WriteString(terminator)
+		offset += len(line)
+	}
+
+	return builder.String()
+}
+
+func splitLines(s string) []string {
+
+	if s == "" {
+		// 空の場合は行が無い
+		return nil
+	}
+
+	lines := strings.SplitAfter(s, "\n")
+
+	// 末尾が改行で終わっている場合、最後の空要素は行として扱わない
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+func cutTerminator(line string) (string, string) {
+
+	if strings.HasSuffix(line, "\r\n") {
+		return line[:len(line)-2], "\r\n"
+	}
+
+	if strings.HasSuffix(line, "\n") {
+		return line[:len(line)-1], "\n"
+	}
+
+	return line, ""
+}
@@ -0,0 +1,69 @@
+package replace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineReplacer(t *testing.T) {
+
+	replacer, err := NewRegexpReplacer("^a|c$", "x")
+	if err != nil {
+		t.Fatal("NewRegexpReplacer failed", err)
+	}
+
+	replacer = NewLineReplacer(replacer)
+
+	{
+		result := replacer.Replace("abc\nabc\n")
+		assert.Equal(t, "xbx\nxbx\n", result)
+	}
+	{
+		result := replacer.Replace("abc\r\nabc\r\nabc")
+		assert.Equal(t, "xbx\r\nxbx\r\nxbx", result)
+	}
+	{
+		result := replacer.Replace("bbb")
+		assert.Equal(t, "bbb", result)
+	}
+	{
+		result := replacer.Replace("")
+		assert.Equal(t, "", result)
+	}
+}
+
+func TestLineReplacer_EmptyLine(t *testing.T) {
+
+	replacer, err := NewRegexpReplacer("^", "> ")
+	if err != nil {
+		t.Fatal("NewRegexpReplacer failed", err)
+	}
+
+	replacer = NewLineReplacer(replacer)
+
+	{
+		result := replacer.Replace("a\n\nb\n")
+		assert.Equal(t, "> a\n> \n> b\n", result)
+	}
+	{
+		result := replacer.Replace("a")
+		assert.Equal(t, "> a", result)
+	}
+	{
+		// 空の場合は行が無いので置換しない
+		result := replacer.Replace("")
+		assert.Equal(t, "", result)
+	}
+}
+
+func TestLineReplacer_String(t *testing.T) {
+
+	replacer := NewLineReplacer(NewStringReplacer("\n", ""))
+
+	{
+		// 改行コードは置換対象にならない
+		result := replacer.Replace("a\nb\n")
+		assert.Equal(t, "a\nb\n", result)
+	}
+}
@@ -0,0 +1,45 @@
+package replace
+
+import (
+	"regexp"
+	"strings"
+)
+
+type Matcher interface {
+	Match(string) bool
+}
+
+type regexpMatcher struct {
+	regex *regexp.Regexp
+}
+
+func NewRegexpMatcher(regexStr string) (Matcher, error) {
+
+	regex, err := regexp.Compile(regexStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &regexpMatcher{
+		regex: regex,
+	}, nil
+}
+
+func (m *regexpMatcher) Match(s string) bool {
+	return m.regex.MatchString(s)
+}
+
+type stringMatcher struct {
+	str string
+}
+
+func NewStringMatcher(str string) Matcher {
+
+	return &stringMatcher{
+		str: str,
+	}
+}
+
+func (m *stringMatcher) Match(s string) bool {
+	return strings.Contains(s, m.str)
+}
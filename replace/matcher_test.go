@@ -0,0 +1,35 @@
+package replace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegexpMatcher(t *testing.T) {
+
+	matcher, err := NewRegexpMatcher("^[a-z]+$")
+	if err != nil {
+		t.Fatal("NewRegexpMatcher failed", err)
+	}
+
+	assert.True(t, matcher.Match("abc"))
+	assert.False(t, matcher.Match("abc1"))
+	assert.False(t, matcher.Match(""))
+}
+
+func TestRegexpMatcher_InvalidRegexp(t *testing.T) {
+
+	_, err := NewRegexpMatcher("[a")
+	assert.EqualError(t, err, "error parsing regexp: missing closing ]: `[a`")
+}
+
+func TestStringMatcher(t *testing.T) {
+
+	matcher := NewStringMatcher("a.c")
+
+	assert.True(t, matcher.Match("a.c"))
+	assert.True(t, matcher.Match("xa.cx"))
+	assert.False(t, matcher.Match("abc"))
+	assert.False(t, matcher.Match(""))
+}
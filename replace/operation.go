@@ -0,0 +1,149 @@
+package replace
+
+import (
+	"strings"
+)
+
+type deleteLineReplacer struct {
+	matcher Matcher
+}
+
+func NewDeleteLineReplacer(matcher Matcher) Replacer {
+
+	return &deleteLineReplacer{
+		matcher: matcher,
+	}
+}
+
+func (r *deleteLineReplacer) Replace(s string) string {
+
+	return r.replaceSelected(s, selectAll)
+}
+
+func (r *deleteLineReplacer) replaceSelected(s string, sel selector) string {
+
+	var builder strings.Builder
+	for _, line := range splitLines(s) {
+		content, _ := cutTerminator(line)
+		if r.matcher.Match(content) && sel() {
+			continue
+		}
+		builder.WriteString(line)
+	}
+
+	return builder.String()
+}
+
+type insertLineReplacer struct {
+	matcher Matcher
+	text    string
+	after   bool
+}
+
+func NewInsertBeforeReplacer(matcher Matcher, text string) Replacer {
+
+	return &insertLineReplacer{
+		matcher: matcher,
+		text:    text,
+		after:   false,
+	}
+}
+
+func NewInsertAfterReplacer(matcher Matcher, text string) Replacer {
+
+	return &insertLineReplacer{
+		matcher: matcher,
+		text:    text,
+		after:   true,
+	}
+}
+
+func (r *insertLineReplacer) Replace(s string) string {
+
+	return r.replaceSelected(s, selectAll)
+}
+
+func (r *insertLineReplacer) replaceSelected(s string, sel selector) string {
+
+	var builder strings.Builder
+	for _, line := range splitLines(s) {
+		content, terminator := cutTerminator(line)
+		if !r.matcher.Match(content) || !sel() {
+			builder.WriteString(line)
+			continue
+		}
+
+		if terminator == "" {
+			// 改行が無い最終行の場合
+			terminator = "\n"
+		}
+
+		if r.after {
+			builder.WriteString(content)
+			builder.WriteString(terminator)
+			builder.WriteString(r.block(terminator))
+		} else {
+			builder.WriteString(r.block(terminator))
+			builder.WriteString(line)
+		}
+	}
+
+	return builder.String()
+}
+
+func (r *insertLineReplacer) block(terminator string) string {
+
+	if strings.HasSuffix(r.text, "\n") {
+		return r.text
+	}
+
+	return r.text + terminator
+}
+
+type ensureLineReplacer struct {
+	matcher Matcher
+	line    string
+}
+
+// 対象の行が無ければ末尾に追加し、あれば最後の行を置き換える
+func NewEnsureLineReplacer(matcher Matcher, line string) Replacer {
+
+	return &ensureLineReplacer{
+		matcher: matcher,
+		line:    line,
+	}
+}
+
+func (r *ensureLineReplacer) Replace(s string) string {
+
+	lines := splitLines(s)
+
+	last := -1
+	for i, line := range lines {
+		content, _ := cutTerminator(line)
+		if r.matcher.Match(content) {
+			last = i
+		}
+	}
+
+	if last != -1 {
+		_, terminator := cutTerminator(lines[last])
+		lines[last] = r.line + terminator
+		return strings.Join(lines, "")
+	}
+
+	// 追加する行の改行コードは、元の最後の改行コードに合わせる
+	terminator := "\n"
+	for i := len(lines) - 1; i >= 0; i-- {
+		if _, t := cutTerminator(lines[i]); t != "" {
+			terminator = t
+			break
+		}
+	}
+
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		return s + terminator + r.line
+	}
+
+	return s + r.line + terminator
+}
@@ -0,0 +1,123 @@
+package replace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteLineReplacer(t *testing.T) {
+
+	replacer := NewDeleteLineReplacer(NewStringMatcher("x"))
+
+	{
+		result := replacer.Replace("a\nx\nb\nxx\n")
+		assert.Equal(t, "a\nb\n", result)
+	}
+	{
+		result := replacer.Replace("a\r\nx\r\nb")
+		assert.Equal(t, "a\r\nb", result)
+	}
+	{
+		result := replacer.Replace("a\nx")
+		assert.Equal(t, "a\n", result)
+	}
+	{
+		result := replacer.Replace("")
+		assert.Equal(t, "", result)
+	}
+}
+
+func TestDeleteLineReplacer_Limit(t *testing.T) {
+
+	replacer := NewLimitReplacer(NewDeleteLineReplacer(NewStringMatcher("x")), 0, 1, 0)
+
+	{
+		result := replacer.Replace("a\nx\nb\nx\n")
+		assert.Equal(t, "a\nb\nx\n", result)
+	}
+}
+
+func TestInsertBeforeReplacer(t *testing.T) {
+
+	matcher, err := NewRegexpMatcher("^b")
+	if err != nil {
+		t.Fatal("NewRegexpMatcher failed", err)
+	}
+
+	replacer := NewInsertBeforeReplacer(matcher, "x")
+
+	{
+		result := replacer.Replace("a\nb\nc\nb")
+		assert.Equal(t, "a\nx\nb\nc\nx\nb", result)
+	}
+	{
+		result := replacer.Replace("a\r\nb\r\n")
+		assert.Equal(t, "a\r\nx\r\nb\r\n", result)
+	}
+	{
+		result := replacer.Replace("a\n")
+		assert.Equal(t, "a\n", result)
+	}
+}
+
+func TestInsertAfterReplacer(t *testing.T) {
+
+	matcher, err := NewRegexpMatcher("^b")
+	if err != nil {
+		t.Fatal("NewRegexpMatcher failed", err)
+	}
+
+	replacer := NewInsertAfterReplacer(matcher, "x\ny\n")
+
+	{
+		result := replacer.Replace("a\nb\nc\n")
+		assert.Equal(t, "a\nb\nx\ny\nc\n", result)
+	}
+	{
+		// 改行が無い最終行
+		result := replacer.Replace("a\nb")
+		assert.Equal(t, "a\nb\nx\ny\n", result)
+	}
+}
+
+func TestEnsureLineReplacer(t *testing.T) {
+
+	matcher, err := NewRegexpMatcher("^port=")
+	if err != nil {
+		t.Fatal("NewRegexpMatcher failed", err)
+	}
+
+	replacer := NewEnsureLineReplacer(matcher, "port=8080")
+
+	{
+		result := replacer.Replace("host=a\nport=80\n")
+		assert.Equal(t, "host=a\nport=8080\n", result)
+	}
+	{
+		// 複数ある場合は最後の行
+		result := replacer.Replace("port=80\nport=81\r\nhost=a\n")
+		assert.Equal(t, "port=80\nport=8080\r\nhost=a\n", result)
+	}
+	{
+		result := replacer.Replace("host=a\n")
+		assert.Equal(t, "host=a\nport=8080\n", result)
+	}
+	{
+		result := replacer.Replace("host=a")
+		assert.Equal(t, "host=a\nport=8080", result)
+	}
+	{
+		// 改行コードは元に合わせる
+		result := replacer.Replace("host=a\r\nuser=b\r\n")
+		assert.Equal(t, "host=a\r\nuser=b\r\nport=8080\r\n", result)
+	}
+	{
+		result := replacer.Replace("host=a\r\nuser=b")
+		assert.Equal(t, "host=a\r\nuser=b\r\nport=8080", result)
+	}
+	{
+		result := replacer.Replace("")
+		assert.Equal(t, "port=8080\n", result)
+	}
+}
@@ -0,0 +1,137 @@
+package replace
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 指定したオフセットのバイト列を書き換える
+type Patch struct {
+	offset   int
+	expected []byte // nilの場合は確認しない
+	bytes    []byte
+}
+
+// offsetは10進数もしくは0xから始まる16進数
+// expectedとbytesはバイナリのパターンと同じ形式 (expectedが空の場合は確認しない)
+func NewPatch(offsetStr string, expectedStr string, bytesStr string) (*Patch, error) {
+
+	offset, err := strconv.ParseInt(offsetStr, 0, 0)
+	if err != nil || offset < 0 {
+		return nil, fmt.Errorf("invalid offset \"%s\"", offsetStr)
+	}
+
+	var expected []byte
+	if expectedStr != "" && expectedStr != "-" {
+		expected, err = parseBinaryBytes(expectedStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := parseBinaryBytes(bytesStr)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("bytes to write at %s is empty", offsetStr)
+	}
+
+	return &Patch{
+		offset:   int(offset),
+		expected: expected,
+		bytes:    b,
+	}, nil
+}
+
+// 1行に1つ、"オフセット 元のバイト列 書き込むバイト列" の形式
+// 元のバイト列を確認しない場合は - を指定、空行と#から始まる行は無視
+func ParsePatches(contents string) ([]*Patch, error) {
+
+	patches := []*Patch{}
+	for i, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields, err := splitPatchFields(line)
+		if err != nil || len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: patch must be \"OFFSET EXPECTED BYTES\"", i+1)
+		}
+
+		patch, err := NewPatch(fields[0], fields[1], fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		patches = append(patches, patch)
+	}
+
+	return patches, nil
+}
+
+// 空白で区切る (ダブルクォートで囲まれた中の空白は区切りとしない)
+func splitPatchFields(line string) ([]string, error) {
+
+	fields := []string{}
+	var builder strings.Builder
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quoted && c == '\\' && i+1 < len(line):
+			builder.WriteByte(c)
+			i++
+			builder.WriteByte(line[i])
+			continue
+		case c == '"':
+			quoted = !quoted
+		case !quoted && (c == ' ' || c == '\t'):
+			if builder.Len() > 0 {
+				fields = append(fields, builder.String())
+				builder.Reset()
+			}
+			continue
+		}
+		builder.WriteByte(c)
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("literal is not closed")
+	}
+	if builder.Len() > 0 {
+		fields = append(fields, builder.String())
+	}
+
+	return fields, nil
+}
+
+// 全てのパッチの元のバイト列を確認してから書き換える (1つでも異なる場合はエラー)
+func ApplyPatches(s string, patches []*Patch) (string, error) {
+
+	for _, patch := range patches {
+		length := len(patch.bytes)
+		if len(patch.expected) > length {
+			length = len(patch.expected)
+		}
+		if patch.offset+length > len(s) {
+			return "", fmt.Errorf("offset 0x%X is out of range (size 0x%X)", patch.offset, len(s))
+		}
+
+		if patch.expected != nil {
+			actual := []byte(s[patch.offset : patch.offset+len(patch.expected)])
+			if !bytes.Equal(actual, patch.expected) {
+				return "", fmt.Errorf("offset 0x%X: expected % X but found % X", patch.offset, patch.expected, actual)
+			}
+		}
+	}
+
+	patched := []byte(s)
+	for _, patch := range patches {
+		copy(patched[patch.offset:], patch.bytes)
+	}
+
+	return string(patched), nil
+}
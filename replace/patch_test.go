@@ -0,0 +1,100 @@
+package replace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPatches(t *testing.T) {
+
+	patch1, err := NewPatch("0x02", "74 05", "EB 05")
+	require.NoError(t, err)
+	patch2, err := NewPatch("6", "", `"OK"`)
+	require.NoError(t, err)
+
+	result, err := ApplyPatches("\x00\x01\x74\x05\x00\x00NG", []*Patch{patch1, patch2})
+	require.NoError(t, err)
+	assert.Equal(t, "\x00\x01\xEB\x05\x00\x00OK", result)
+}
+
+func TestApplyPatches_Mismatch(t *testing.T) {
+
+	patch1, err := NewPatch("0", "00", "FF")
+	require.NoError(t, err)
+	patch2, err := NewPatch("1", "74 05", "EB 05")
+	require.NoError(t, err)
+
+	// 1つでも異なる場合は、何も書き換えない
+	_, err = ApplyPatches("\x00\x75\x05", []*Patch{patch1, patch2})
+	require.Error(t, err)
+	assert.Equal(t, "offset 0x1: expected 74 05 but found 75 05", err.Error())
+}
+
+func TestApplyPatches_OutOfRange(t *testing.T) {
+
+	patch, err := NewPatch("0x10", "", "FF FF")
+	require.NoError(t, err)
+
+	_, err = ApplyPatches("\x00\x00", []*Patch{patch})
+	require.Error(t, err)
+	assert.Equal(t, "offset 0x10 is out of range (size 0x2)", err.Error())
+}
+
+func TestNewPatch_Invalid(t *testing.T) {
+
+	{
+		_, err := NewPatch("x", "", "00")
+		require.Error(t, err)
+		assert.Equal(t, "invalid offset \"x\"", err.Error())
+	}
+	{
+		_, err := NewPatch("-1", "", "00")
+		require.Error(t, err)
+		assert.Equal(t, "invalid offset \"-1\"", err.Error())
+	}
+	{
+		_, err := NewPatch("0", "??", "00")
+		require.Error(t, err)
+		assert.Equal(t, "invalid binary pattern \"??\": ?? is not allowed", err.Error())
+	}
+	{
+		_, err := NewPatch("0", "", "")
+		require.Error(t, err)
+		assert.Equal(t, "bytes to write at 0 is empty", err.Error())
+	}
+}
+
+func TestParsePatches(t *testing.T) {
+
+	contents := "# offset expected new\r\n0x00 0001 FFFF\r\n\r\n  16  \"a b\"  \"c\\\"d\"\n0x20 - 90\n"
+
+	patches, err := ParsePatches(contents)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*Patch{
+		{offset: 0x00, expected: []byte{0x00, 0x01}, bytes: []byte{0xFF, 0xFF}},
+		{offset: 16, expected: []byte("a b"), bytes: []byte(`c"d`)},
+		{offset: 0x20, bytes: []byte{0x90}},
+	}, patches)
+}
+
+func TestParsePatches_Invalid(t *testing.T) {
+
+	{
+		_, err := ParsePatches("0x00 00\n")
+		require.Error(t, err)
+		assert.Equal(t, "line 1: patch must be \"OFFSET EXPECTED BYTES\"", err.Error())
+	}
+	{
+		_, err := ParsePatches("0x00 00 00\n0x01 00 \"a\n")
+		require.Error(t, err)
+		assert.Equal(t, "line 2: patch must be \"OFFSET EXPECTED BYTES\"", err.Error())
+	}
+	{
+		_, err := ParsePatches("\n0x00 00 0\n")
+		require.Error(t, err)
+		assert.Equal(t, "line 2: invalid binary pattern \"0\": hex must be 2 digits", err.Error())
+	}
+}
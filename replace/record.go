@@ -0,0 +1,71 @@
+package replace
+
+// 置換した箇所 (位置は置換前の文字列でのもの)
+type Match struct {
+	Start       int
+	End         int
+	Replacement string
+}
+
+// 置換した箇所を記録
+type MatchRecorder struct {
+	matches []Match
+}
+
+func NewMatchRecorder() *MatchRecorder {
+
+	return &MatchRecorder{}
+}
+
+// 前回のReset以降に置換した箇所
+func (c *MatchRecorder) Matches() []Match {
+
+	return c.matches
+}
+
+func (c *MatchRecorder) Reset() {
+
+	c.matches = nil
+}
+
+type recordingReplacer struct {
+	replacer Replacer
+	recorder *MatchRecorder
+}
+
+// 置換した箇所をMatchRecorderに記録する
+// 位置は受け取った文字列でのものなので、範囲を指定するReplacerよりも内側で使う場合は注意
+func NewRecordingReplacer(replacer Replacer, recorder *MatchRecorder) Replacer {
+
+	return &recordingReplacer{
+		replacer: replacer,
+		recorder: recorder,
+	}
+}
+
+func (r *recordingReplacer) Replace(s string) string {
+
+	return r.replaceSelected(s, selectAll)
+}
+
+func (r *recordingReplacer) replaceSelected(s string, sel selector) string {
+
+	finder, ok := r.replacer.(matchFinder)
+	if !ok {
+		// マッチ単位で扱えないもの(行の削除など)は記録できない
+		return replaceSelected(r.replacer, s, sel)
+	}
+
+	matches := finder.findMatches(s)
+	index := -1
+	return replaceMatches(s, matches, func() bool {
+		index++
+		if !sel() {
+			return false
+		}
+
+		m := matches[index]
+		r.recorder.matches = append(r.recorder.matches, Match{Start: m.start, End: m.end, Replacement: m.replacement})
+		return true
+	})
+}
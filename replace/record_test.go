@@ -0,0 +1,52 @@
+package replace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingReplacer(t *testing.T) {
+
+	recorder := NewMatchRecorder()
+
+	regexpReplacer, err := NewRegexpReplacer("a+", "b")
+	require.NoError(t, err)
+	replacer := NewRecordingReplacer(regexpReplacer, recorder)
+
+	result := replacer.Replace("xaxaay")
+	assert.Equal(t, "xbxby", result)
+	assert.Equal(t, []Match{
+		{Start: 1, End: 2, Replacement: "b"},
+		{Start: 3, End: 5, Replacement: "b"},
+	}, recorder.Matches())
+
+	recorder.Reset()
+	assert.Empty(t, recorder.Matches())
+}
+
+func TestRecordingReplacer_Selected(t *testing.T) {
+
+	recorder := NewMatchRecorder()
+
+	binaryReplacer, err := NewBinaryReplacer("00", "FF FF")
+	require.NoError(t, err)
+	replacer := NewLimitReplacer(NewRecordingReplacer(binaryReplacer, recorder), -1, 0, 0)
+
+	// 置換しなかったマッチは記録しない
+	result := replacer.Replace("\x00\x01\x00")
+	assert.Equal(t, "\x00\x01\xFF\xFF", result)
+	assert.Equal(t, []Match{{Start: 2, End: 3, Replacement: "\xFF\xFF"}}, recorder.Matches())
+}
+
+func TestRecordingReplacer_NotMatchFinder(t *testing.T) {
+
+	recorder := NewMatchRecorder()
+
+	replacer := NewRecordingReplacer(NewDeleteLineReplacer(NewStringMatcher("a")), recorder)
+
+	result := replacer.Replace("a\nb\n")
+	assert.Equal(t, "b\n", result)
+	assert.Empty(t, recorder.Matches())
+}
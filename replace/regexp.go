@@ -1,27 +1,43 @@
-package replace
-
-import (
-	"regexp"
-)
-
-type regexpReplacer struct {
-	regex       *regexp.Regexp
-	replacement string
-}
-
-func NewRegexpReplacer(regexStr string, replacement string) (Replacer, error) {
-
-	regex, err := regexp.Compile(regexStr)
-	if err != nil {
-		return nil, err
-	}
-
-	return &regexpReplacer{
-		regex:       regex,
-		replacement: replacement,
-	}, nil
-}
-
-func (r *regexpReplacer) Replace(s string) string {
-	return r.regex.ReplaceAllString(s, r.replacement)
-}
+package replace
+
+import (
+	"regexp"
+)
+
+type regexpReplacer struct {
+	regex       *regexp.Regexp
+	replacement string
+}
+
+func NewRegexpReplacer(regexStr string, replacement string) (Replacer, error) {
+
+	regex, err := regexp.Compile(regexStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &regexpReplacer{
+		regex:       regex,
+		replacement: replacement,
+	}, nil
+}
+
+func (r *regexpReplacer) Replace(s string) string {
+	return r.regex.ReplaceAllString(s, r.replacement)
+}
+
+func (r *regexpReplacer) replaceSelected(s string, sel selector) string {
+
+	return replaceMatches(s, r.findMatches(s), sel)
+}
+
+func (r *regexpReplacer) findMatches(s string) []match {
+
+	matches := []match{}
+	for _, index := range r.regex.FindAllStringSubmatchIndex(s, -1) {
+		replacement := string(r.regex.ExpandString(nil, r.replacement, s, index))
+		matches = append(matches, match{start: index[0], end: index[1], replacement: replacement})
+	}
+
+	return matches
+}
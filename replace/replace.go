@@ -1,5 +1,10 @@
-package replace
-
-type Replacer interface {
-	Replace(string) string
-}
+package replace
+
+type Replacer interface {
+	Replace(string) string
+}
+
+// 文字列に変換せずにバイト列のまま置換できるもの
+type BytesReplacer interface {
+	ReplaceBytes([]byte) []byte
+}
@@ -0,0 +1,218 @@
+package replace
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+type Scope interface {
+	Regions(string) [][]int
+}
+
+type scopedReplacer struct {
+	replacer Replacer
+	scope    Scope
+}
+
+func NewScopedReplacer(replacer Replacer, scope Scope) Replacer {
+
+	return &scopedReplacer{
+		replacer: replacer,
+		scope:    scope,
+	}
+}
+
+func (r *scopedReplacer) Replace(s string) string {
+
+	return r.replaceAt(s, 0, nil)
+}
+
+func (r *scopedReplacer) replaceSelected(s string, sel selector) string {
+
+	return r.replaceAt(s, 0, sel)
+}
+
+func (r *scopedReplacer) replaceAt(s string, offset int, sel selector) string {
+
+	var builder strings.Builder
+	last := 0
+	for _, region := range r.scope.Regions(s) {
+		// 範囲外はそのまま、範囲内だけを置換
+		builder.WriteString(s[last:region[0]])
+		builder.WriteString(replaceAt(r.replacer, s[region[0]:region[1]], offset+region[0], sel))
+		last = region[1]
+	}
+	builder.WriteString(s[last:])
+
+	return builder.String()
+}
+
+type lineRangeScope struct {
+	start int
+	end   int
+}
+
+// 行番号は1始まりで、startとendの行を含む
+// endに0を指定した場合は最終行まで
+func NewLineRangeScope(start int, end int) Scope {
+
+	return &lineRangeScope{
+		start: start,
+		end:   end,
+	}
+}
+
+func (c *lineRangeScope) Regions(s string) [][]int {
+
+	begin := -1
+	offset := 0
+	for i, line := range splitLines(s) {
+		lineNumber := i + 1
+		if begin == -1 && lineNumber >= c.start {
+			begin = offset
+		}
+
+		offset += len(line)
+
+		if c.end != 0 && lineNumber == c.end {
+			break
+		}
+	}
+
+	if begin == -1 {
+		return nil
+	}
+
+	return [][]int{{begin, offset}}
+}
+
+type betweenScope struct {
+	start     *regexp.Regexp
+	end       *regexp.Regexp
+	inclusive bool
+}
+
+func NewBetweenScope(startRegexStr string, endRegexStr string, inclusive bool) (Scope, error) {
+
+	start, err := regexp.Compile(startRegexStr)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := regexp.Compile(endRegexStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &betweenScope{
+		start:     start,
+		end:       end,
+		inclusive: inclusive,
+	}, nil
+}
+
+func (c *betweenScope) Regions(s string) [][]int {
+
+	regions := [][]int{}
+	pos := 0
+	for pos <= len(s) {
+
+		startLoc := c.start.FindStringIndex(s[pos:])
+		if startLoc == nil {
+			break
+		}
+		startLoc[0] += pos
+		startLoc[1] += pos
+
+		endLoc := c.end.FindStringIndex(s[startLoc[1]:])
+		if endLoc == nil {
+			// 終了のマーカーが無い場合は範囲としない
+			break
+		}
+		endLoc[0] += startLoc[1]
+		endLoc[1] += startLoc[1]
+
+		if c.inclusive {
+			regions = append(regions, []int{startLoc[0], endLoc[1]})
+		} else {
+			regions = append(regions, []int{startLoc[1], endLoc[0]})
+		}
+
+		if endLoc[1] == pos {
+			// 空文字にマッチし続けて進まなくなるのを防ぐ
+			_, width := utf8.DecodeRuneInString(s[pos:])
+			if width == 0 {
+				break
+			}
+			endLoc[1] += width
+		}
+		pos = endLoc[1]
+	}
+
+	return regions
+}
+
+type afterScope struct {
+	anchor    *regexp.Regexp
+	inclusive bool
+}
+
+func NewAfterScope(anchorRegexStr string, inclusive bool) (Scope, error) {
+
+	anchor, err := regexp.Compile(anchorRegexStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &afterScope{
+		anchor:    anchor,
+		inclusive: inclusive,
+	}, nil
+}
+
+func (c *afterScope) Regions(s string) [][]int {
+
+	loc := c.anchor.FindStringIndex(s)
+	if loc == nil {
+		return nil
+	}
+
+	if c.inclusive {
+		return [][]int{{loc[0], len(s)}}
+	}
+
+	return [][]int{{loc[1], len(s)}}
+}
+
+type beforeScope struct {
+	anchor    *regexp.Regexp
+	inclusive bool
+}
+
+func NewBeforeScope(anchorRegexStr string, inclusive bool) (Scope, error) {
+
+	anchor, err := regexp.Compile(anchorRegexStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &beforeScope{
+		anchor:    anchor,
+		inclusive: inclusive,
+	}, nil
+}
+
+func (c *beforeScope) Regions(s string) [][]int {
+
+	loc := c.anchor.FindStringIndex(s)
+	if loc == nil {
+		return nil
+	}
+
+	if c.inclusive {
+		return [][]int{{0, loc[1]}}
+	}
+
+	return [][]int{{0, loc[0]}}
+}
@@ -0,0 +1,148 @@
+package replace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineRangeScope(t *testing.T) {
+
+	replacer := NewScopedReplacer(NewStringReplacer("a", "x"), NewLineRangeScope(2, 3))
+
+	{
+		result := replacer.Replace("a\na\na\na\n")
+		assert.Equal(t, "a\nx\nx\na\n", result)
+	}
+	{
+		result := replacer.Replace("a\na")
+		assert.Equal(t, "a\nx", result)
+	}
+	{
+		result := replacer.Replace("a")
+		assert.Equal(t, "a", result)
+	}
+}
+
+func TestLineRangeScope_ToEnd(t *testing.T) {
+
+	replacer := NewScopedReplacer(NewStringReplacer("a", "x"), NewLineRangeScope(2, 0))
+
+	{
+		result := replacer.Replace("a\na\na\na\n")
+		assert.Equal(t, "a\nx\nx\nx\n", result)
+	}
+}
+
+func TestBetweenScope(t *testing.T) {
+
+	scope, err := NewBetweenScope("BEGIN", "END", false)
+	require.NoError(t, err)
+
+	replacer := NewScopedReplacer(NewStringReplacer("a", "x"), scope)
+
+	{
+		result := replacer.Replace("a\nBEGIN\na\nEND\na\nBEGIN\naa\nEND\na")
+		assert.Equal(t, "a\nBEGIN\nx\nEND\na\nBEGIN\nxx\nEND\na", result)
+	}
+	{
+		// 終了マーカーが無い
+		result := replacer.Replace("a\nBEGIN\na\n")
+		assert.Equal(t, "a\nBEGIN\na\n", result)
+	}
+}
+
+func TestBetweenScope_Inclusive(t *testing.T) {
+
+	scope, err := NewBetweenScope("<a>", "</a>", true)
+	require.NoError(t, err)
+
+	replacer := NewScopedReplacer(NewStringReplacer("a", "x"), scope)
+
+	{
+		result := replacer.Replace("a<a>a</a>a")
+		assert.Equal(t, "a<x>x</x>a", result)
+	}
+}
+
+func TestBetweenScope_Empty(t *testing.T) {
+
+	scope, err := NewBetweenScope("", "", false)
+	require.NoError(t, err)
+
+	replacer := NewScopedReplacer(NewStringReplacer("a", "x"), scope)
+
+	{
+		result := replacer.Replace("aaa")
+		assert.Equal(t, "aaa", result)
+	}
+}
+
+func TestBetweenScope_InvalidRegexp(t *testing.T) {
+
+	{
+		_, err := NewBetweenScope("[a", "b", false)
+		assert.EqualError(t, err, "error parsing regexp: missing closing ]: `[a`")
+	}
+	{
+		_, err := NewBetweenScope("a", "[b", false)
+		assert.EqualError(t, err, "error parsing regexp: missing closing ]: `[b`")
+	}
+}
+
+func TestAfterScope(t *testing.T) {
+
+	{
+		scope, err := NewAfterScope("-", false)
+		require.NoError(t, err)
+
+		replacer := NewScopedReplacer(NewStringReplacer("-", "x"), scope)
+		result := replacer.Replace("a-b-c")
+		assert.Equal(t, "a-bxc", result)
+	}
+	{
+		scope, err := NewAfterScope("-", true)
+		require.NoError(t, err)
+
+		replacer := NewScopedReplacer(NewStringReplacer("-", "x"), scope)
+		result := replacer.Replace("a-b-c")
+		assert.Equal(t, "axbxc", result)
+	}
+	{
+		scope, err := NewAfterScope("z", false)
+		require.NoError(t, err)
+
+		replacer := NewScopedReplacer(NewStringReplacer("-", "x"), scope)
+		result := replacer.Replace("a-b-c")
+		assert.Equal(t, "a-b-c", result)
+	}
+}
+
+func TestBeforeScope(t *testing.T) {
+
+	{
+		scope, err := NewBeforeScope("-", false)
+		require.NoError(t, err)
+
+		replacer := NewScopedReplacer(NewStringReplacer("a", "x"), scope)
+		result := replacer.Replace("aa-aa")
+		assert.Equal(t, "xx-aa", result)
+	}
+	{
+		scope, err := NewBeforeScope("a-", true)
+		require.NoError(t, err)
+
+		replacer := NewScopedReplacer(NewStringReplacer("a", "x"), scope)
+		result := replacer.Replace("aa-aa")
+		assert.Equal(t, "xx-aa", result)
+	}
+	{
+		scope, err := NewBeforeScope("z", false)
+		require.NoError(t, err)
+
+		replacer := NewScopedReplacer(NewStringReplacer("a", "x"), scope)
+		result := replacer.Replace("aa-aa")
+		assert.Equal(t, "aa-aa", result)
+	}
+}
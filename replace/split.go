@@ -0,0 +1,63 @@
+package replace
+
+// 文字列の部分ごとにReplacerを適用する関数 (構造化されたファイルの値ごとに置換する場合など)
+type SplitFunc func(string, Replacer) (string, error)
+
+type SplitReplacer struct {
+	replacer Replacer
+	split    SplitFunc
+	err      error
+}
+
+// 部分ごとに置換しつつ、外側からは1つの文字列に対するReplacerとして扱う
+// 置換数の制限などは、全ての部分を通したマッチ順で判定される
+func NewSplitReplacer(replacer Replacer, split SplitFunc) *SplitReplacer {
+
+	return &SplitReplacer{
+		replacer: replacer,
+		split:    split,
+	}
+}
+
+func (r *SplitReplacer) Replace(s string) string {
+
+	return r.replaceWith(s, r.replacer)
+}
+
+func (r *SplitReplacer) replaceSelected(s string, sel selector) string {
+
+	return r.replaceWith(s, &selectedReplacer{replacer: r.replacer, sel: sel})
+}
+
+func (r *SplitReplacer) replaceWith(s string, replacer Replacer) string {
+
+	replaced, err := r.split(s, replacer)
+	if err != nil {
+		// Replaceはエラーを返せないので、後からErrで取得する
+		if r.err == nil {
+			r.err = err
+		}
+		return s
+	}
+
+	return replaced
+}
+
+// 置換中に発生したエラー (取得するとクリアされる)
+func (r *SplitReplacer) Err() error {
+
+	err := r.err
+	r.err = nil
+	return err
+}
+
+// 部分ごとの置換でも、同じselectorでマッチ順に選別する
+type selectedReplacer struct {
+	replacer Replacer
+	sel      selector
+}
+
+func (r *selectedReplacer) Replace(s string) string {
+
+	return replaceSelected(r.replacer, s, r.sel)
+}
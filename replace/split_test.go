@@ -0,0 +1,78 @@
+package replace
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// カンマ区切りの値ごとに置換
+func splitComma(s string, replacer Replacer) (string, error) {
+
+	if strings.HasPrefix(s, "!") {
+		return "", errors.New("invalid")
+	}
+
+	values := strings.Split(s, ",")
+	for i, value := range values {
+		values[i] = replacer.Replace(value)
+	}
+
+	return strings.Join(values, ","), nil
+}
+
+func TestSplitReplacer(t *testing.T) {
+
+	replacer := NewSplitReplacer(NewStringReplacer("a", "x"), splitComma)
+
+	result := replacer.Replace("a,ba,c")
+	assert.Equal(t, "x,bx,c", result)
+	assert.NoError(t, replacer.Err())
+}
+
+func TestSplitReplacer_Limit(t *testing.T) {
+
+	split := NewSplitReplacer(NewStringReplacer("a", "x"), splitComma)
+
+	{
+		// マッチ順は全ての値を通して数える
+		result := NewLimitReplacer(split, 1, 0, 0).Replace("a,a,aa")
+		assert.Equal(t, "x,a,aa", result)
+	}
+	{
+		result := NewLimitReplacer(split, -1, 0, 0).Replace("a,a,aa")
+		assert.Equal(t, "a,a,ax", result)
+	}
+	{
+		result := NewLimitReplacer(split, 0, 3, 0).Replace("a,a,aa")
+		assert.Equal(t, "x,x,xa", result)
+	}
+}
+
+func TestSplitReplacer_Guard(t *testing.T) {
+
+	matcher, err := NewRegexpMatcher("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 条件は値ごとではなく全体で判定
+	replacer := NewIfReplacer(NewSplitReplacer(NewStringReplacer("a", "x"), splitComma), matcher)
+
+	assert.Equal(t, "x,b", replacer.Replace("a,b"))
+	assert.Equal(t, "a,c", replacer.Replace("a,c"))
+}
+
+func TestSplitReplacer_Error(t *testing.T) {
+
+	replacer := NewSplitReplacer(NewStringReplacer("a", "x"), splitComma)
+
+	result := replacer.Replace("!a")
+	assert.Equal(t, "!a", result)
+	assert.EqualError(t, replacer.Err(), "invalid")
+
+	// 取得するとクリアされる
+	assert.NoError(t, replacer.Err())
+}
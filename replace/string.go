@@ -1,20 +1,66 @@
-package replace
-
-import "strings"
-
-type stringReplacer struct {
-	old string
-	new string
-}
-
-func NewStringReplacer(old string, new string) Replacer {
-
-	return &stringReplacer{
-		old: old,
-		new: new,
-	}
-}
-
-func (r *stringReplacer) Replace(s string) string {
-	return strings.ReplaceAll(s, r.old, r.new)
-}
+package replace
+
+import (
+	"bytes"
+	"strings"
+)
+
+type stringReplacer struct {
+	old string
+	new string
+}
+
+func NewStringReplacer(old string, new string) Replacer {
+
+	return &stringReplacer{
+		old: old,
+		new: new,
+	}
+}
+
+func (r *stringReplacer) Replace(s string) string {
+	return strings.ReplaceAll(s, r.old, r.new)
+}
+
+func (r *stringReplacer) ReplaceBytes(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte(r.old), []byte(r.new))
+}
+
+func (r *stringReplacer) replaceSelected(s string, sel selector) string {
+
+	return replaceMatches(s, r.findMatches(s), sel)
+}
+
+func (r *stringReplacer) findMatches(s string) []match {
+
+	matches := []match{}
+	for _, index := range r.indexAll(s) {
+		matches = append(matches, match{start: index, end: index + len(r.old), replacement: r.new})
+	}
+
+	return matches
+}
+
+func (r *stringReplacer) indexAll(s string) []int {
+
+	indexes := []int{}
+
+	if r.old == "" {
+		// strings.ReplaceAll と同じく、各文字の前と末尾にマッチ
+		for i := range s {
+			indexes = append(indexes, i)
+		}
+		return append(indexes, len(s))
+	}
+
+	for pos := 0; ; {
+		i := strings.Index(s[pos:], r.old)
+		if i == -1 {
+			break
+		}
+		indexes = append(indexes, pos+i)
+		pos += i + len(r.old)
+	}
+
+	return indexes
+}
@@ -1,29 +1,37 @@
-package replace
-
-import (
-	"testing"
-
-	"github.com/stretchr/testify/assert"
-)
-
-func TestStringReplacer(t *testing.T) {
-
-	replacer := NewStringReplacer("abc", "xyz")
-
-	{
-		result := replacer.Replace("abc")
-		assert.Equal(t, "xyz", result)
-	}
-	{
-		result := replacer.Replace(" abcabc\nabcABC\n")
-		assert.Equal(t, " xyzxyz\nxyzABC\n", result)
-	}
-	{
-		result := replacer.Replace("")
-		assert.Equal(t, "", result)
-	}
-	{
-		result := replacer.Replace("aaaa")
-		assert.Equal(t, "aaaa", result)
-	}
-}
+package replace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringReplacer(t *testing.T) {
+
+	replacer := NewStringReplacer("abc", "xyz")
+
+	{
+		result := replacer.Replace("abc")
+		assert.Equal(t, "xyz", result)
+	}
+	{
+		result := replacer.Replace(" abcabc\nabcABC\n")
+		assert.Equal(t, " xyzxyz\nxyzABC\n", result)
+	}
+	{
+		result := replacer.Replace("")
+		assert.Equal(t, "", result)
+	}
+	{
+		result := replacer.Replace("aaaa")
+		assert.Equal(t, "aaaa", result)
+	}
+}
+
+func TestStringReplacer_ReplaceBytes(t *testing.T) {
+
+	replacer := NewStringReplacer("\x00\x01", "\xFF")
+
+	result := replacer.(BytesReplacer).ReplaceBytes([]byte{0x00, 0x01, 0x02, 0x00, 0x01})
+	assert.Equal(t, []byte{0xFF, 0x02, 0xFF}, result)
+}
@@ -0,0 +1,46 @@
+package syntax
+
+import (
+	"fmt"
+)
+
+// ソースコードのコメントや文字列リテラルなどの範囲
+// 言語はファイルごとに異なるので、処理するファイルのパスをSetPathで設定してから使う
+type Scope struct {
+	kind     Kind
+	language *Language
+}
+
+func NewScope(kindStr string) (*Scope, error) {
+
+	var kind Kind
+	switch kindStr {
+	case "comments":
+		kind = Comments
+	case "strings":
+		kind = Strings
+	case "code":
+		kind = Code
+	default:
+		return nil, fmt.Errorf("unknown scope \"%s\"", kindStr)
+	}
+
+	return &Scope{
+		kind: kind,
+	}, nil
+}
+
+func (s *Scope) SetPath(path string) {
+
+	s.language = LanguageOf(path)
+}
+
+func (s *Scope) Regions(src string) [][]int {
+
+	if s.language == nil {
+		// 対応していない言語のファイルは対象外
+		return nil
+	}
+
+	return s.language.Regions(src, s.kind)
+}
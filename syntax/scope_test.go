@@ -0,0 +1,53 @@
+package syntax
+
+import (
+	"testing"
+
+	"github.com/onozaty/rcf/replace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScope(t *testing.T) {
+
+	scope, err := NewScope("comments")
+	require.NoError(t, err)
+
+	replacer := replace.NewScopedReplacer(replace.NewStringReplacer("foo", "bar"), scope)
+
+	{
+		scope.SetPath("a.go")
+		result := replacer.Replace("foo() // foo\n")
+		assert.Equal(t, "foo() // bar\n", result)
+	}
+	{
+		scope.SetPath("a.py")
+		result := replacer.Replace("foo() // foo # foo\n")
+		assert.Equal(t, "foo() // foo # bar\n", result)
+	}
+	{
+		// 対応していない言語は対象外
+		scope.SetPath("a.txt")
+		result := replacer.Replace("foo() // foo\n")
+		assert.Equal(t, "foo() // foo\n", result)
+	}
+}
+
+func TestScope_Code(t *testing.T) {
+
+	scope, err := NewScope("code")
+	require.NoError(t, err)
+	scope.SetPath("A.java")
+
+	replacer := replace.NewScopedReplacer(replace.NewStringReplacer("foo", "bar"), scope)
+
+	result := replacer.Replace("foo(\"foo\"); // foo\nfoo();")
+	assert.Equal(t, "bar(\"foo\"); // foo\nbar();", result)
+}
+
+func TestNewScope_Invalid(t *testing.T) {
+
+	_, err := NewScope("xxx")
+	require.Error(t, err)
+	assert.Equal(t, "unknown scope \"xxx\"", err.Error())
+}
@@ -0,0 +1,250 @@
+package syntax
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+type Kind int
+
+const (
+	Comments Kind = iota
+	Strings
+	Code
+)
+
+type quote struct {
+	delimiter string
+	escape    bool // \ によるエスケープ
+	double    bool // 区切り文字を重ねたエスケープ (SQLの '')
+	multiline bool
+}
+
+// コメントと文字列リテラルを判別するための、言語ごとの定義
+type Language struct {
+	lineComments  []string
+	blockComments [][2]string
+	quotes        []quote // 長い区切り文字を先に
+	wordComment   bool    // 単語の先頭のみ行コメントとする (シェルの#)
+}
+
+var (
+	cFamily = &Language{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes: []quote{
+			{delimiter: `"`, escape: true},
+			{delimiter: `'`, escape: true},
+		},
+	}
+	java = &Language{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes: []quote{
+			{delimiter: `"""`, escape: true, multiline: true},
+			{delimiter: `"`, escape: true},
+			{delimiter: `'`, escape: true},
+		},
+	}
+	golang = &Language{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes: []quote{
+			{delimiter: `"`, escape: true},
+			{delimiter: `'`, escape: true},
+			{delimiter: "`", multiline: true},
+		},
+	}
+	javaScript = &Language{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes: []quote{
+			{delimiter: `"`, escape: true},
+			{delimiter: `'`, escape: true},
+			{delimiter: "`", escape: true, multiline: true},
+		},
+	}
+	python = &Language{
+		lineComments: []string{"#"},
+		quotes: []quote{
+			{delimiter: `"""`, escape: true, multiline: true},
+			{delimiter: `'''`, escape: true, multiline: true},
+			{delimiter: `"`, escape: true},
+			{delimiter: `'`, escape: true},
+		},
+	}
+	sql = &Language{
+		lineComments:  []string{"--"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes: []quote{
+			{delimiter: `'`, double: true, multiline: true},
+		},
+	}
+	shell = &Language{
+		lineComments: []string{"#"},
+		quotes: []quote{
+			{delimiter: `"`, escape: true, multiline: true},
+			{delimiter: `'`, multiline: true},
+		},
+		wordComment: true,
+	}
+)
+
+var extensions = map[string]*Language{
+	".go":    golang,
+	".java":  java,
+	".kt":    java,
+	".kts":   java,
+	".scala": java,
+	".c":     cFamily,
+	".h":     cFamily,
+	".cc":    cFamily,
+	".cpp":   cFamily,
+	".cxx":   cFamily,
+	".hpp":   cFamily,
+	".cs":    cFamily,
+	".swift": cFamily,
+	".js":    javaScript,
+	".mjs":   javaScript,
+	".cjs":   javaScript,
+	".jsx":   javaScript,
+	".ts":    javaScript,
+	".tsx":   javaScript,
+	".py":    python,
+	".sql":   sql,
+	".sh":    shell,
+	".bash":  shell,
+	".zsh":   shell,
+}
+
+// ファイルの拡張子から言語を判定 (対応していない場合はnil)
+func LanguageOf(path string) *Language {
+
+	return extensions[strings.ToLower(filepath.Ext(path))]
+}
+
+type token struct {
+	kind Kind
+	// 区切り文字を含む範囲
+	start int
+	end   int
+	// 区切り文字を除いた内容の範囲
+	contentStart int
+	contentEnd   int
+}
+
+// 指定した種類の範囲を返す
+// コメントと文字列は区切り文字を除いた内容、コードはコメントと文字列以外の範囲
+func (l *Language) Regions(s string, kind Kind) [][]int {
+
+	regions := [][]int{}
+	last := 0
+	for _, t := range l.tokens(s) {
+		switch kind {
+		case Code:
+			if last < t.start {
+				regions = append(regions, []int{last, t.start})
+			}
+			last = t.end
+		case t.kind:
+			if t.contentStart < t.contentEnd {
+				regions = append(regions, []int{t.contentStart, t.contentEnd})
+			}
+		}
+	}
+
+	if kind == Code && last < len(s) {
+		regions = append(regions, []int{last, len(s)})
+	}
+
+	return regions
+}
+
+func (l *Language) tokens(s string) []token {
+
+	tokens := []token{}
+	pos := 0
+	for pos < len(s) {
+		if t, ok := l.scanComment(s, pos); ok {
+			tokens = append(tokens, t)
+			pos = t.end
+			continue
+		}
+		if t, ok := l.scanString(s, pos); ok {
+			tokens = append(tokens, t)
+			pos = t.end
+			continue
+		}
+		pos++
+	}
+
+	return tokens
+}
+
+func (l *Language) scanComment(s string, pos int) (token, bool) {
+
+	rest := s[pos:]
+
+	for _, delimiters := range l.blockComments {
+		if !strings.HasPrefix(rest, delimiters[0]) {
+			continue
+		}
+		contentStart := pos + len(delimiters[0])
+		end := strings.Index(s[contentStart:], delimiters[1])
+		if end == -1 {
+			// 閉じられていない場合は末尾まで
+			return token{kind: Comments, start: pos, end: len(s), contentStart: contentStart, contentEnd: len(s)}, true
+		}
+		contentEnd := contentStart + end
+		return token{kind: Comments, start: pos, end: contentEnd + len(delimiters[1]), contentStart: contentStart, contentEnd: contentEnd}, true
+	}
+
+	for _, delimiter := range l.lineComments {
+		if !strings.HasPrefix(rest, delimiter) {
+			continue
+		}
+		if l.wordComment && pos > 0 && !strings.ContainsRune(" \t\r\n;|&()", rune(s[pos-1])) {
+			continue
+		}
+		contentStart := pos + len(delimiter)
+		end := strings.IndexAny(s[contentStart:], "\r\n")
+		if end == -1 {
+			end = len(s)
+		} else {
+			end += contentStart
+		}
+		return token{kind: Comments, start: pos, end: end, contentStart: contentStart, contentEnd: end}, true
+	}
+
+	return token{}, false
+}
+
+func (l *Language) scanString(s string, pos int) (token, bool) {
+
+	rest := s[pos:]
+
+	for _, q := range l.quotes {
+		if !strings.HasPrefix(rest, q.delimiter) {
+			continue
+		}
+		contentStart := pos + len(q.delimiter)
+		for i := contentStart; i < len(s); i++ {
+			switch {
+			case q.escape && s[i] == '\\':
+				i++
+			case !q.multiline && (s[i] == '\n' || s[i] == '\r'):
+				// 閉じられないまま行が終わった場合は、行末まで
+				return token{kind: Strings, start: pos, end: i, contentStart: contentStart, contentEnd: i}, true
+			case strings.HasPrefix(s[i:], q.delimiter):
+				if q.double && strings.HasPrefix(s[i+len(q.delimiter):], q.delimiter) {
+					i += len(q.delimiter)*2 - 1
+					continue
+				}
+				return token{kind: Strings, start: pos, end: i + len(q.delimiter), contentStart: contentStart, contentEnd: i}, true
+			}
+		}
+		return token{kind: Strings, start: pos, end: len(s), contentStart: contentStart, contentEnd: len(s)}, true
+	}
+
+	return token{}, false
+}
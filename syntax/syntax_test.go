@@ -0,0 +1,68 @@
+package syntax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func regionStrings(s string, regions [][]int) []string {
+
+	strs := []string{}
+	for _, region := range regions {
+		strs = append(strs, s[region[0]:region[1]])
+	}
+	return strs
+}
+
+func TestLanguageOf(t *testing.T) {
+
+	assert.Equal(t, golang, LanguageOf("a/b/main.go"))
+	assert.Equal(t, javaScript, LanguageOf("index.TS"))
+	assert.Equal(t, python, LanguageOf("setup.py"))
+	assert.Nil(t, LanguageOf("README.md"))
+	assert.Nil(t, LanguageOf("Makefile"))
+}
+
+func TestRegions_Go(t *testing.T) {
+
+	s := "// TODO: a\npackage main\n\n/* TODO\n b */\nvar s = \"TODO \\\"x\\\"\" + `raw\n` + 'a' // TODO\n"
+
+	assert.Equal(t, []string{" TODO: a", " TODO\n b ", " TODO"}, regionStrings(s, golang.Regions(s, Comments)))
+	assert.Equal(t, []string{"TODO \\\"x\\\"", "raw\n", "a"}, regionStrings(s, golang.Regions(s, Strings)))
+	assert.Equal(t, []string{"\npackage main\n\n", "\nvar s = ", " + ", " + ", " ", "\n"}, regionStrings(s, golang.Regions(s, Code)))
+}
+
+func TestRegions_Python(t *testing.T) {
+
+	s := "x = '#a' # b\n\"\"\"doc\n'c'\"\"\"\ny = \"d\n"
+
+	assert.Equal(t, []string{" b"}, regionStrings(s, python.Regions(s, Comments)))
+	// 閉じられていない文字列は行末まで
+	assert.Equal(t, []string{"#a", "doc\n'c'", "d"}, regionStrings(s, python.Regions(s, Strings)))
+}
+
+func TestRegions_SQL(t *testing.T) {
+
+	s := "SELECT 'it''s' -- c\n/* d */ FROM \"t\""
+
+	assert.Equal(t, []string{" c", " d "}, regionStrings(s, sql.Regions(s, Comments)))
+	assert.Equal(t, []string{"it''s"}, regionStrings(s, sql.Regions(s, Strings)))
+	assert.Equal(t, []string{"SELECT ", " ", "\n", " FROM \"t\""}, regionStrings(s, sql.Regions(s, Code)))
+}
+
+func TestRegions_Shell(t *testing.T) {
+
+	s := "# a\necho $# \"b # c\" 'd\\' e#f # g\n"
+
+	assert.Equal(t, []string{" a", " g"}, regionStrings(s, shell.Regions(s, Comments)))
+	assert.Equal(t, []string{"b # c", "d\\"}, regionStrings(s, shell.Regions(s, Strings)))
+}
+
+func TestRegions_JavaScript(t *testing.T) {
+
+	s := "const a = `x\n${y}`; /* z"
+
+	assert.Equal(t, []string{" z"}, regionStrings(s, javaScript.Regions(s, Comments)))
+	assert.Equal(t, []string{"x\n${y}"}, regionStrings(s, javaScript.Regions(s, Strings)))
+}